@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"reflect"
@@ -25,7 +26,12 @@ func NewMainActorOne() *MainActorOne {
 
 func (a *MainActorOne) Process(msg actor.Message) {
 	a.lastMsg = msg
-	slog.Info("app one", slog.String("lastmsg", a.lastMsg.String()))
+	switch payload := msg.Body.(type) {
+	case MsgBody:
+		slog.Info("app one", slog.String("text", payload.Text), slog.String("from", msg.From.String()))
+	default:
+		slog.Info("app one", slog.String("lastmsg", a.lastMsg.String()))
+	}
 }
 
 func (a *MainActorOne) GetState() any {
@@ -41,16 +47,19 @@ type MsgBody struct {
 }
 
 func main() {
-	natsToken := os.Getenv("NATS_SECRET")
-	nc, err := nats.Connect(nats.DefaultURL, nats.Token(natsToken))
-	if err != nil {
-		panic(err.Error())
-	}
-
 	reg := actor.EnvelopePayloadTypeRegistry{
 		"main.MsgBody": reflect.TypeOf(MsgBody{}),
 	}
-	actor.InitPostman(actor.WithOutboundMessageService("app1", nc, reg))
+	_, err := actor.InitPostman(actor.WithNatsOutboundMessageService(
+		"app1",
+		reg,
+		actor.WithNatsURLs(nats.DefaultURL),
+		actor.WithNatsToken(os.Getenv("NATS_SECRET")),
+		actor.WithNatsReconnect(-1, 2*time.Second),
+	))
+	if err != nil {
+		panic(err.Error())
+	}
 
 	ma := NewMainActorOne()
 	_, err = actor.RegisterActor(MainActorOneAddress, ma)
@@ -59,7 +68,12 @@ func main() {
 	}
 
 	slog.Info("app one")
-	<-time.After(30 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	report, err := actor.RunUntilSignal(ctx, actor.WithShutdownTimeout(5*time.Second))
+	if err != nil {
+		slog.Warn("app one shut down with errors", slog.String("err", err.Error()))
+	}
 
-	slog.Info("end --- app one")
+	slog.Info("end --- app one", slog.Int("dropped", report.DroppedMessages()))
 }
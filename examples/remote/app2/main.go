@@ -39,15 +39,18 @@ type MsgBody struct {
 }
 
 func main() {
-	natsToken := os.Getenv("NATS_SECRET")
-	nc, err := nats.Connect(nats.DefaultURL, nats.Token(natsToken))
+	reg := actor.EnvelopePayloadTypeRegistry{}
+	_, err := actor.InitPostman(actor.WithNatsOutboundMessageService(
+		"app2",
+		reg,
+		actor.WithNatsURLs(nats.DefaultURL),
+		actor.WithNatsToken(os.Getenv("NATS_SECRET")),
+		actor.WithNatsReconnect(-1, 2*time.Second),
+	))
 	if err != nil {
 		panic(err.Error())
 	}
 
-	reg := actor.EnvelopePayloadTypeRegistry{}
-	actor.InitPostman(actor.WithOutboundMessageService("app2", nc, reg))
-
 	slog.Info("app two")
 
 	fromAddress := actor.NewAddress("local", "actor-two")
@@ -2,14 +2,16 @@ module github.com/pix303/cinecity
 
 go 1.24.0
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/nats-io/nats.go v1.49.0
+	github.com/nats-io/nuid v1.0.1
+	github.com/stretchr/testify v1.10.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/klauspost/compress v1.18.2 // indirect
-	github.com/nats-io/nats.go v1.49.0 // indirect
 	github.com/nats-io/nkeys v0.4.12 // indirect
-	github.com/nats-io/nuid v1.0.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
@@ -5,35 +5,334 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const drainPollInterval = 5 * time.Millisecond
+
+// stopGracePeriod is the time given to the message in progress of an actor not drained within the shutdown deadline
+// to return after its processing context is cancelled
+const stopGracePeriod = 100 * time.Millisecond
+
+// DefaultDropTimeout is the time Drop waits for the message in progress to be processed
+const DefaultDropTimeout = 5 * time.Second
+
 var (
 	ErrInboxClosed           = errors.New("actor has inbox closed")
 	ErrSendWithReturnTimeout = errors.New("message with retrun has not be processed in time")
+	ErrProcessorPanic        = errors.New("state processor panicked processing the message")
+	ErrActorStartFailed      = errors.New("actor failed to start")
 )
 
 type Actor struct {
-	address        *Address
-	MessageBox     chan Message
-	isClosed       bool
+	address    *Address
+	system     *ActorSystem
+	supervisor *Address
+	mailbox    Mailbox
+	mutex      sync.RWMutex
+	isClosed   bool
+	isDropped  bool
+	// run is nil when the actor is not processing the message box: not activated, suspended waiting for a restart or dropped
+	run *actorRun
+	// suspended is set when a failure is reported to the supervisor, until the actor is restarted
+	suspended      bool
 	stateProcessor StateProcessor
+	// seq is the registration order in the system
+	seq uint64
+	// processed counts the messages received from the mailbox and processed or discarded
+	processed atomic.Uint64
+	// internalMessages counts the messages of the runtime in the mailbox, like state queries, that are not reported as dropped messages
+	internalMessages atomic.Int64
+	// timers are the schedules of the actor scheduler, created on first use
+	timers *timerSet
+	// parent is the actor that spawned this one, nil for the actors registered on the system
+	parent   *Actor
+	children []*Actor
+	// watchers receive Terminated when the actor terminates, watching are the actors watched by this one
+	watchers []*Address
+	watching []*Address
+	// snapshotInterval is the period of the snapshots of the state, 0 to save them only on demand and on shutdown
+	snapshotInterval time.Duration
+	dropHooks        []func()
+	drainHooks       []func()
+}
+
+// ActorOption configures an actor at registration
+type ActorOption func(*Actor)
+
+// actorRun tracks the goroutine that processes the message box
+type actorRun struct {
+	stop chan struct{}
+	done chan struct{}
+	// ctx is cancelled when the run is halted or the system shuts down, cancelling the processing context of the message in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// halt stops the goroutine after the message in progress, whose processing context is cancelled
+func (run *actorRun) halt() {
+	close(run.stop)
+	run.cancel()
+}
+
+// WithMailbox sets the mailbox of the actor, instead of the default one of the system
+func WithMailbox(mailbox Mailbox) ActorOption {
+	return func(a *Actor) {
+		a.mailbox = mailbox
+	}
+}
+
+func withSupervisor(supervisor *Address) ActorOption {
+	return func(a *Actor) {
+		a.supervisor = supervisor
+	}
 }
 
 func (a *Actor) Activate() {
-	if a.IsClosed() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.isClosed && !a.isDropped {
 		slog.Info("Actor activated", slog.String("address", a.address.String()))
 		a.isClosed = false
-		p := a.stateProcessor
-		if p != nil {
-			go a.processMessage(a.MessageBox)
+		if a.run == nil {
+			a.startProcessing()
 		}
 	}
 }
 
-func (a *Actor) processMessage(inboxChan <-chan Message) {
-	for msg := range inboxChan {
-		a.stateProcessor.Process(msg)
+// startProcessing starts the goroutine processing the message box; the caller must hold the lock
+func (a *Actor) startProcessing() {
+	p := a.stateProcessor
+	if p == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(a.system.context)
+	run := &actorRun{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	a.run = run
+	go a.processMessage(p, a.mailbox, run)
+}
+
+// stopProcessing stops the goroutine processing the message box and waits for the message in progress
+func (a *Actor) stopProcessing() {
+	a.mutex.Lock()
+	run := a.run
+	a.run = nil
+	a.mutex.Unlock()
+
+	if run != nil {
+		run.halt()
+		<-run.done
+	}
+}
+
+func (a *Actor) processMessage(p StateProcessor, mailbox Mailbox, run *actorRun) {
+	defer close(run.done)
+	defer run.cancel()
+	for {
+		msg, ok := mailbox.Receive(run.stop)
+		if !ok {
+			return
+		}
+		switch msg.Body.(type) {
+		case stateQuery, drainNotice:
+			a.internalMessages.Add(-1)
+		}
+		if err := msg.Context().Err(); err != nil {
+			discardMessage(a.address, msg, err)
+			a.processed.Add(1)
+			continue
+		}
+		if a.handleSystemMessage(p, msg) {
+			a.processed.Add(1)
+			continue
+		}
+		reason, failed := a.processSafely(p, msg, run)
+		a.processed.Add(1)
+		if failed {
+			if a.notifyFailure(msg, reason) {
+				// the actor is suspended until its supervisor restarts it, watchers are notified on restart
+				a.mutex.Lock()
+				if a.run == run {
+					a.run = nil
+					a.suspended = true
+				}
+				a.mutex.Unlock()
+				return
+			}
+			a.notifyWatchers(fmt.Errorf("%w: %v", ErrProcessorPanic, reason))
+		}
+	}
+}
+
+// discardMessage skips a message whose sender has given up, replying the context error if the message waits for a response
+func discardMessage(address *Address, msg Message, err error) {
+	slog.Debug("message discarded", slog.String("address", address.String()), slog.String("err", err.Error()))
+	if msg.WithResponse && msg.ResponseChan != nil {
+		select {
+		case msg.ResponseChan <- NewReturnMessage(nil, msg, err):
+		default:
+		}
+	}
+}
+
+// processingContext returns the context of the message, carrying the actor, that is cancelled also when the system shuts down
+// or the actor is dropped
+func (a *Actor) processingContext(msg Message, run *actorRun) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithValue(msg.Context(), actorContextKey{}, a))
+	stop := context.AfterFunc(run.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// handleSystemMessage processes the requests of the runtime on the actor goroutine, reporting if the message was one of them
+func (a *Actor) handleSystemMessage(p StateProcessor, msg Message) bool {
+	switch msg.Body.(type) {
+	case snapshotRequest:
+		a.handleSnapshotRequest(p, msg)
+	case stateQuery:
+		if msg.WithResponse && msg.ResponseChan != nil {
+			msg.ResponseChan <- NewReturnMessage(p.GetState(), msg, nil)
+		}
+	case drainNotice:
+		a.runDrainHooks(msg.Body.(drainNotice))
+	default:
+		return false
+	}
+	return true
+}
+
+// processSafely processes the message recovering a panic of the state processor.
+// If the message waits for a response, the caller receives ErrProcessorPanic.
+func (a *Actor) processSafely(p StateProcessor, msg Message, run *actorRun) (reason any, failed bool) {
+	ctx, cancel := a.processingContext(msg, run)
+	defer cancel()
+	msg = msg.WithContext(ctx)
+
+	defer func() {
+		if r := recover(); r != nil {
+			reason = r
+			failed = true
+			if msg.WithResponse && msg.ResponseChan != nil {
+				select {
+				case msg.ResponseChan <- NewReturnMessage(nil, msg, fmt.Errorf("%w: %v", ErrProcessorPanic, r)):
+				default:
+				}
+			}
+		}
+	}()
+	if cp, ok := p.(ContextStateProcessor); ok {
+		cp.ProcessContext(ctx, msg)
+	} else {
+		p.Process(msg)
+	}
+	return nil, false
+}
+
+// notifyFailure reports the failure to the supervisor, if any, and returns true if the actor must be suspended
+func (a *Actor) notifyFailure(msg Message, reason any) bool {
+	slog.Error("state processor failed", slog.String("address", a.address.String()), slog.Any("reason", reason))
+	if a.supervisor == nil {
+		return false
+	}
+
+	failure := NewMessage(a.supervisor, a.address, ChildFailed{Child: a.address, Reason: reason, Message: msg})
+	err := a.system.SendMessage(failure)
+	if err != nil {
+		slog.Error("supervisor not reachable, actor keeps processing", slog.String("address", a.address.String()), slog.String("err", err.Error()))
+		return false
+	}
+	return true
+}
+
+// restart replaces the state processor with a new one and restarts processing the message box, that keeps the pending messages.
+// The lifecycle hooks are called in order: PreRestart, Shutdown and PostStop on the old state processor, PreStart and PostRestart on the new one.
+func (a *Actor) restart(p StateProcessor, reason any, msg Message) error {
+	a.stopProcessing()
+
+	a.mutex.Lock()
+	if a.isDropped {
+		a.mutex.Unlock()
+		return ErrInboxClosed
+	}
+	old := a.stateProcessor
+	a.stateProcessor = p
+	a.mutex.Unlock()
+
+	if old != nil {
+		if h, ok := old.(PreRestarter); ok {
+			h.PreRestart(reason, msg)
+		}
+		stopProcessor(old)
+	}
+	a.notifyWatchers(fmt.Errorf("%w: %v", ErrActorRestarted, reason))
+
+	err := startProcessor(a, p)
+	if err != nil {
+		// the actor stays suspended without state processor, until the supervisor restarts it again
+		a.mutex.Lock()
+		a.stateProcessor = nil
+		a.suspended = true
+		a.mutex.Unlock()
+		a.notifyFailure(msg, err)
+		return err
+	}
+
+	if h, ok := p.(PostRestarter); ok {
+		h.PostRestart(reason)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.suspended = false
+	if !a.isDropped && a.run == nil {
+		a.startProcessing()
+	}
+	slog.Info("Actor restarted", slog.String("address", a.address.String()))
+	return nil
+}
+
+// startProcessor restores the latest snapshot, calls PreStart and binds the state processor to the actor; if binding fails the state processor is stopped
+func startProcessor(a *Actor, p StateProcessor) error {
+	ctx := context.WithValue(a.system.context, actorContextKey{}, a)
+	err := a.restoreSnapshot(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if h, ok := p.(PreStarter); ok {
+		err := h.PreStart(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrActorStartFailed, err)
+		}
+	}
+
+	if b, ok := p.(actorBinder); ok {
+		err := b.bindActor(a)
+		if err != nil {
+			if h, ok := p.(PostStopper); ok {
+				h.PostStop()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// stopProcessor calls Shutdown and PostStop on the state processor
+func stopProcessor(p StateProcessor) {
+	p.Shutdown()
+	if h, ok := p.(PostStopper); ok {
+		h.PostStop()
 	}
 }
 
@@ -42,31 +341,87 @@ func (a *Actor) GetAddress() *Address {
 }
 
 func (a *Actor) IsClosed() bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
 	return a.isClosed
 }
 
 func (a *Actor) Deactivate() {
-	if !a.IsClosed() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.isClosed {
 		a.isClosed = true
 		slog.Info("Actor deactivated", slog.String("address", a.address.String()))
 	}
 }
 
+// Inbox posts the message in the actor mailbox; depending on the mailbox it can block, drop the message or fail when full.
+// During a graceful shutdown of the system only the actors can still post messages, see SendMessage.
 func (a *Actor) Inbox(msg Message) error {
-	if a.isClosed {
+	if a.system.rejects(msg) {
 		return ErrInboxClosed
 	}
-	a.MessageBox <- msg
-	return nil
+	return a.post(msg)
+}
+
+// post posts the message in the actor mailbox if the inbox is open, see Inbox
+func (a *Actor) post(msg Message) error {
+	a.mutex.RLock()
+	closed := a.isClosed
+	a.mutex.RUnlock()
+
+	if closed {
+		return ErrInboxClosed
+	}
+	return a.mailbox.Post(msg)
+}
+
+// PostSelf posts a message with body from the actor to itself, e.g. a result completed while processing a message.
+// The mailboxes of the package enqueue it beyond their capacity, without the overflow policy, so the actor never blocks on its own
+// mailbox; a message that can't be posted is reported to the dead letter office.
+func (a *Actor) PostSelf(body any) error {
+	msg := NewMessage(a.address, a.address, body)
+	err := a.postSelf(msg)
+	if err != nil {
+		a.system.deadLetter(msg, err)
+	}
+	return err
 }
 
+func (a *Actor) postSelf(msg Message) error {
+	a.mutex.RLock()
+	closed := a.isClosed
+	a.mutex.RUnlock()
+
+	if closed {
+		return ErrInboxClosed
+	}
+	if mailbox, ok := a.mailbox.(forcePoster); ok {
+		return mailbox.forcePost(msg)
+	}
+	return a.mailbox.Post(msg)
+}
+
+// MailboxMetrics returns depth and counters of the actor mailbox
+func (a *Actor) MailboxMetrics() MailboxMetrics {
+	return a.mailbox.Metrics()
+}
+
+// InboxAndWaitResponse posts the message and waits for the response within the message timeout or until the message context is done.
+// The state processor receives the message context with the timeout as deadline.
 func (a *Actor) InboxAndWaitResponse(msg Message) (Message, error) {
-	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Duration(msg.ResponseTimeout)*time.Second)
+	return a.postAndWait(msg, a.Inbox)
+}
+
+// postAndWait posts the message with post and waits for the response, see InboxAndWaitResponse
+func (a *Actor) postAndWait(msg Message, post func(Message) error) (Message, error) {
+	ctx, cancelFunc := context.WithTimeout(msg.Context(), time.Duration(msg.ResponseTimeout)*time.Second)
 	defer cancelFunc()
 
 	returnChan := msg.ResponseChan
 
-	err := a.Inbox(msg)
+	err := post(msg.WithContext(ctx))
 	if err != nil {
 		return EmptyMessage, err
 	}
@@ -75,28 +430,204 @@ func (a *Actor) InboxAndWaitResponse(msg Message) (Message, error) {
 	case returnMsg := <-returnChan:
 		return *returnMsg.Message, returnMsg.Err
 	case <-ctx.Done():
-		return EmptyMessage, ErrSendWithReturnTimeout
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return EmptyMessage, ErrSendWithReturnTimeout
+		}
+		return EmptyMessage, ctx.Err()
 	}
 }
 
+// Drop shutdowns the state processor, closes the message box and removes the actor from its system; it is safe to call it more than once.
+// The children of the actor are dropped before it, from the last spawned, and the watchers receive Terminated.
+// The processing context of the message in progress is cancelled and the state processor is shut down after it is processed,
+// waiting at most DefaultDropTimeout; an actor dropping itself while processing a message calls DropContext with the processing context.
 func (a *Actor) Drop() {
+	a.dropWithin(DefaultDropTimeout)
+}
+
+// DropContext drops the actor like Drop, waiting for the message in progress until it is processed or ctx is done.
+// If ctx is the processing context of the actor, the actor is dropping itself and the message in progress is not waited for.
+func (a *Actor) DropContext(ctx context.Context) {
+	a.mutex.Lock()
+	if a.isDropped {
+		a.mutex.Unlock()
+		return
+	}
 	mp := a.stateProcessor
+	a.stateProcessor = nil
+	a.isDropped = true
+	run := a.run
+	a.run = nil
+	if run != nil {
+		run.halt()
+	}
+	if !a.isClosed {
+		a.isClosed = true
+		slog.Info("Actor deactivated", slog.String("address", a.address.String()))
+	}
+	a.mailbox.Close()
+	timers := a.timers
+	children := a.children
+	a.children = nil
+	watchers := a.watchers
+	watching := a.watching
+	a.watchers = nil
+	a.watching = nil
+	dropHooks := a.dropHooks
+	a.dropHooks = nil
+	a.drainHooks = nil
+	a.mutex.Unlock()
+
+	// the state processor is stopped after the message in progress, unless the actor is dropping itself while processing it
+	if self, _ := ActorFromContext(ctx); run != nil && self != a {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			slog.Warn("actor dropped while processing a message", slog.String("address", a.address.String()))
+		}
+	}
+
+	for i := len(children) - 1; i >= 0; i-- {
+		children[i].Drop()
+	}
+	if timers != nil {
+		timers.cancelAll(true)
+	}
+	for _, hook := range dropHooks {
+		hook()
+	}
 	if mp != nil {
-		mp.Shutdown()
+		stopProcessor(mp)
+	}
+	a.system.registry.remove(a.address, a)
+	if a.parent != nil {
+		a.parent.removeChild(a)
+	}
+
+	for _, target := range watching {
+		a.system.unwatchTarget(a.address, target)
+	}
+	for _, watcher := range watchers {
+		a.system.notifyTerminated(watcher, a.address, ErrActorDropped)
+	}
+	if a != a.system.deadLetterActor {
+		a.system.events.Unsubscribe(a.address)
+		a.system.events.Publish(TopicActorDropped, ActorDropped{Address: a.address})
 	}
-	a.Deactivate()
-	UnRegisterActor(a.address)
-	a.address = nil
-	a.stateProcessor = nil
-	close(a.MessageBox)
 }
 
-func (a *Actor) GetState() any {
-	mp := a.stateProcessor
-	if mp != nil {
-		return mp.GetState()
+// dropWithin drops the actor waiting at most timeout for the message in progress
+func (a *Actor) dropWithin(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	a.DropContext(ctx)
+}
+
+// OnDrop adds a function called when the actor is dropped, before the state processor Shutdown, e.g. to flush buffers of the state.
+// Functions are called in order of registration; f is called at once if the actor is already dropped.
+func (a *Actor) OnDrop(f func()) {
+	a.mutex.Lock()
+	if !a.isDropped {
+		a.dropHooks = append(a.dropHooks, f)
+		a.mutex.Unlock()
+		return
 	}
-	return nil
+	a.mutex.Unlock()
+	f()
+}
+
+// OnDrain adds a function called by the actor goroutine when a graceful shutdown of the system stops the actor, after the messages
+// already in the mailbox and before the inbox is closed, e.g. to post to the actor the messages buffered by the state.
+// Functions are called in order of registration; they are not called if the actor is dropped or suspended.
+func (a *Actor) OnDrain(f func()) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if !a.isDropped {
+		a.drainHooks = append(a.drainHooks, f)
+	}
+}
+
+// drainNotice is processed by the actor goroutine, between two messages, to call the drain hooks
+type drainNotice struct {
+	done chan struct{}
+}
+
+func (a *Actor) runDrainHooks(notice drainNotice) {
+	defer close(notice.done)
+	a.mutex.Lock()
+	hooks := a.drainHooks
+	a.drainHooks = nil
+	a.mutex.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// postInternal posts a message of the runtime, skipping the check of the closed inbox
+func (a *Actor) postInternal(msg Message) error {
+	a.internalMessages.Add(1)
+	err := a.mailbox.Post(msg)
+	if err != nil {
+		a.internalMessages.Add(-1)
+	}
+	return err
+}
+
+// notifyDrain makes the actor goroutine call the drain hooks and waits for them until ctx is done
+func (a *Actor) notifyDrain(ctx context.Context) {
+	a.mutex.RLock()
+	run := a.run
+	hooks := len(a.drainHooks)
+	a.mutex.RUnlock()
+	if run == nil || hooks == 0 || ctx.Err() != nil {
+		return
+	}
+
+	notice := drainNotice{done: make(chan struct{})}
+	err := a.postInternal(NewMessage(a.address, nil, notice))
+	if err != nil {
+		slog.Warn("actor drain hooks not called", slog.String("address", a.address.String()), slog.String("err", err.Error()))
+		return
+	}
+	select {
+	case <-notice.done:
+	case <-run.done:
+	case <-ctx.Done():
+	}
+}
+
+// drain calls the drain hooks, closes the inbox and waits until the messages in the mailbox are processed or ctx is done;
+// it reports if the mailbox has been drained
+func (a *Actor) drain(ctx context.Context) bool {
+	a.notifyDrain(ctx)
+	a.Deactivate()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		if a.isIdle() {
+			return true
+		}
+		// the mailbox of a suspended actor, or of one without state processor, is not processed until a restart
+		a.mutex.RLock()
+		processing := a.run != nil
+		a.mutex.RUnlock()
+		if !processing {
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return a.isIdle()
+		case <-ticker.C:
+		}
+	}
+}
+
+// isIdle reports if the mailbox is empty and every message received from it has been processed
+func (a *Actor) isIdle() bool {
+	m := a.mailbox.Metrics()
+	return m.Depth == 0 && a.processed.Load() == m.Delivered
 }
 
 func (a *Actor) String() string {
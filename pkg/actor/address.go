@@ -23,6 +23,9 @@ func NewAddress(area, id string) *Address {
 const OutboundPrefix string = "cinecity"
 const AddressSeparator string = "."
 
+// ChildSeparator separates the names of the ancestors in the id of a child actor, like "orders/order-1/payment"
+const ChildSeparator string = "/"
+
 // NewOutboundAddress creates a new address with the given area and id for sending message to remote application.
 func NewOutboundAddress(outboundArea, area, id string) *Address {
 	return &Address{
@@ -72,6 +75,38 @@ func (addr *Address) IsInbound() bool {
 	return !addr.IsOutbound()
 }
 
+// Child returns the address of the child actor with the given name, in the same area
+func (addr *Address) Child(name string) *Address {
+	return &Address{
+		addr.area,
+		addr.id + ChildSeparator + name,
+		addr.outboundArea,
+	}
+}
+
+// Parent returns the address of the parent actor, nil if the address is not of a child actor
+func (addr *Address) Parent() *Address {
+	i := strings.LastIndex(addr.id, ChildSeparator)
+	if i < 0 {
+		return nil
+	}
+	return &Address{
+		addr.area,
+		addr.id[:i],
+		addr.outboundArea,
+	}
+}
+
+// Name returns the last part of the id, that is the id for an actor that is not a child
+func (addr *Address) Name() string {
+	return addr.id[strings.LastIndex(addr.id, ChildSeparator)+1:]
+}
+
+// IsDescendantOf reports if the address is of a child, or a child of a child, of the given address
+func (addr *Address) IsDescendantOf(address *Address) bool {
+	return addr.area == address.area && strings.HasPrefix(addr.id, address.id+ChildSeparator)
+}
+
 func GetOutboundAreaPrefix(outboundArea string) string {
 	return fmt.Sprintf("%s.%s", OutboundPrefix, outboundArea)
 }
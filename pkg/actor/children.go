@@ -0,0 +1,100 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrChildNameInvalid   = errors.New("child name is invalid: it is empty or contains the child separator")
+	ErrActorNotInContext  = errors.New("context is not the processing context of an actor")
+	ErrParentActorDropped = errors.New("parent actor is dropped")
+)
+
+// Spawn creates a child actor of the actor, with address a.GetAddress().Child(name).
+// Children are dropped with their parent, before it.
+func (a *Actor) Spawn(name string, processor StateProcessor, opts ...ActorOption) (*Actor, error) {
+	if name == "" || strings.Contains(name, ChildSeparator) {
+		return nil, ErrChildNameInvalid
+	}
+	return a.system.register(a.address.Child(name), processor, a, opts...)
+}
+
+// Spawn creates a child of the actor processing the message, or starting, whose context is ctx; see Actor.Spawn
+func Spawn(ctx context.Context, name string, processor StateProcessor, opts ...ActorOption) (*Actor, error) {
+	parent, ok := ActorFromContext(ctx)
+	if !ok {
+		return nil, ErrActorNotInContext
+	}
+	return parent.Spawn(name, processor, opts...)
+}
+
+func (a *Actor) addChild(child *Actor) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.isDropped {
+		return ErrParentActorDropped
+	}
+	a.children = append(a.children, child)
+	return nil
+}
+
+func (a *Actor) removeChild(child *Actor) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for i, c := range a.children {
+		if c == child {
+			a.children = append(a.children[:i], a.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// Parent returns the actor that spawned this one, nil if it has been registered on the system
+func (a *Actor) Parent() *Actor {
+	return a.parent
+}
+
+// Children returns the children of the actor, in spawn order
+func (a *Actor) Children() []*Actor {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return append([]*Actor{}, a.children...)
+}
+
+// Child returns the child with the given name, nil if not found
+func (a *Actor) Child(name string) *Actor {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	for _, c := range a.children {
+		if c.address.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Subtree returns the actor followed by its descendants, depth first in spawn order
+func (a *Actor) Subtree() []*Actor {
+	result := []*Actor{a}
+	for _, c := range a.Children() {
+		result = append(result, c.Subtree()...)
+	}
+	return result
+}
+
+// Subtree returns the registered actors with the given address or descending from it, in registration order
+func (s *ActorSystem) Subtree(address *Address) []*Actor {
+	result := make([]*Actor, 0)
+	for _, a := range s.registry.snapshot() {
+		if a.address.IsEqual(address) || a.address.IsDescendantOf(address) {
+			result = append(result, a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].seq < result[j].seq
+	})
+	return result
+}
@@ -0,0 +1,146 @@
+package actor_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type SpawnOrder struct {
+	Name string
+}
+
+// orderManager spawns a child for every SpawnOrder received and replies with its address
+type orderManager struct {
+	log *lifecycleLog
+}
+
+func (p *orderManager) Process(msg actor.Message) {
+	body, ok := msg.Body.(SpawnOrder)
+	if !ok {
+		return
+	}
+	child, err := actor.Spawn(msg.Context(), body.Name, &lifecycleProcessor{name: body.Name, log: p.log})
+	if msg.WithResponse {
+		var address *actor.Address
+		if child != nil {
+			address = child.GetAddress()
+		}
+		msg.ResponseChan <- actor.NewReturnMessage(address, msg, err)
+	}
+}
+
+func (p *orderManager) Shutdown() {
+	p.log.add("manager:shutdown")
+}
+
+func (p *orderManager) GetState() any {
+	return nil
+}
+
+func TestAddressHierarchy(t *testing.T) {
+	parent := actor.NewAddress("orders", "manager")
+	child := parent.Child("order-1")
+	grandchild := child.Child("payment")
+
+	assert.Equal(t, "orders.manager/order-1/payment", grandchild.String())
+	assert.Equal(t, "payment", grandchild.Name())
+	assert.Equal(t, "manager", parent.Name())
+	assert.True(t, grandchild.Parent().IsEqual(child))
+	assert.Nil(t, parent.Parent())
+	assert.True(t, grandchild.IsDescendantOf(parent))
+	assert.False(t, parent.IsDescendantOf(parent))
+	assert.False(t, actor.NewAddress("orders", "manager-2").IsDescendantOf(parent))
+}
+
+func TestSpawnFromProcess(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	manager, err := s.RegisterActor(actor.NewAddress("orders", "manager"), &orderManager{log: log})
+	assert.NoError(t, err)
+
+	address, err := actor.AskAs[*actor.Address](s, actor.NewMessageWithResponse(manager.GetAddress(), nil, SpawnOrder{Name: "order-1"}))
+	assert.NoError(t, err)
+	assert.True(t, address.IsEqual(manager.GetAddress().Child("order-1")))
+
+	_, err = actor.AskAs[*actor.Address](s, actor.NewMessageWithResponse(manager.GetAddress(), nil, SpawnOrder{Name: "order-1"}))
+	assert.Equal(t, actor.ErrActorAddressAlreadyRegistered, err)
+	_, err = actor.AskAs[*actor.Address](s, actor.NewMessageWithResponse(manager.GetAddress(), nil, SpawnOrder{Name: "a/b"}))
+	assert.Equal(t, actor.ErrChildNameInvalid, err)
+
+	child := manager.Child("order-1")
+	assert.NotNil(t, child)
+	assert.Same(t, manager, child.Parent())
+	assert.Nil(t, manager.Parent())
+
+	counter := &atomic.Int64{}
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, "hello")), "children are reachable by address")
+
+	_, err = actor.Spawn(context.Background(), "orphan", &countingProcessor{counter})
+	assert.Equal(t, actor.ErrActorNotInContext, err)
+}
+
+func TestChildrenDroppedWithParent(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	manager, err := s.RegisterActor(actor.NewAddress("orders", "manager"), &orderManager{log: log})
+	assert.NoError(t, err)
+	first, err := manager.Spawn("order-1", &lifecycleProcessor{name: "order-1", log: log})
+	assert.NoError(t, err)
+	_, err = manager.Spawn("order-2", &lifecycleProcessor{name: "order-2", log: log})
+	assert.NoError(t, err)
+	_, err = first.Spawn("payment", &lifecycleProcessor{name: "payment", log: log})
+	assert.NoError(t, err)
+	_, err = s.RegisterActor(actor.NewAddress("orders", "manager-2"), &orderManager{log: log})
+	assert.NoError(t, err)
+
+	ids := func(actors []*actor.Actor) []string {
+		result := make([]string, 0, len(actors))
+		for _, a := range actors {
+			result = append(result, a.GetAddress().ID())
+		}
+		return result
+	}
+	expected := []string{"manager", "manager/order-1", "manager/order-1/payment", "manager/order-2"}
+	assert.Equal(t, expected, ids(manager.Subtree()))
+	assert.ElementsMatch(t, expected, ids(s.Subtree(manager.GetAddress())))
+	assert.Equal(t, 5, s.NumActors())
+
+	manager.Drop()
+	assert.Equal(t, []string{
+		"order-1:pre-start", "order-2:pre-start", "payment:pre-start",
+		"order-2:shutdown", "order-2:post-stop",
+		"payment:shutdown", "payment:post-stop",
+		"order-1:shutdown", "order-1:post-stop",
+		"manager:shutdown",
+	}, log.get(), "children are stopped before the parent, from the last spawned")
+	assert.Equal(t, 1, s.NumActors())
+	assert.Empty(t, manager.Children())
+
+	_, err = manager.Spawn("late", &lifecycleProcessor{name: "late", log: log})
+	assert.Equal(t, actor.ErrParentActorDropped, err)
+	assert.Equal(t, 1, s.NumActors())
+}
+
+func TestDroppedChildLeavesParent(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	manager, err := s.RegisterActor(actor.NewAddress("orders", "manager"), &orderManager{log: log})
+	assert.NoError(t, err)
+	child, err := manager.Spawn("order-1", &lifecycleProcessor{name: "order-1", log: log})
+	assert.NoError(t, err)
+
+	child.Drop()
+	assert.Empty(t, manager.Children())
+	_, err = manager.Spawn("order-1", &lifecycleProcessor{name: "order-1", log: log})
+	assert.NoError(t, err, "name of a dropped child can be reused")
+}
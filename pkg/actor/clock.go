@@ -0,0 +1,133 @@
+package actor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of the scheduler, it can be replaced with a ManualClock in tests
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after the duration elapses
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+type ClockTimer interface {
+	// Stop prevents the timer from firing, it returns false if the timer already fired or has been stopped
+	Stop() bool
+}
+
+type realClock struct{}
+
+// RealClock is the clock of the system time
+var RealClock Clock = realClock{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
+
+// ManualClock is a Clock that moves only when Advance or Set are called, so timers fire deterministically
+type ManualClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	seq    uint64
+	timers []*manualTimer
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	due   time.Time
+	seq   uint64
+	f     func()
+}
+
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{
+		now: start,
+	}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.seq++
+	t := &manualTimer{clock: c, due: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, see Set
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to t and fires the timers due until t in order of due time, synchronously in the calling goroutine.
+// While a timer fires, the clock reports its due time, so timers created by the callback are relative to it.
+func (c *ManualClock) Set(t time.Time) {
+	for {
+		c.mutex.Lock()
+		next := c.nextDue(t)
+		if next == nil {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mutex.Unlock()
+			return
+		}
+		c.removeLocked(next)
+		if next.due.After(c.now) {
+			c.now = next.due
+		}
+		c.mutex.Unlock()
+
+		next.f()
+	}
+}
+
+// Pending returns the number of timers not yet fired or stopped
+func (c *ManualClock) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+func (c *ManualClock) nextDue(until time.Time) *manualTimer {
+	sort.Slice(c.timers, func(i, j int) bool {
+		if c.timers[i].due.Equal(c.timers[j].due) {
+			return c.timers[i].seq < c.timers[j].seq
+		}
+		return c.timers[i].due.Before(c.timers[j].due)
+	})
+	if len(c.timers) > 0 && !c.timers[0].due.After(until) {
+		return c.timers[0]
+	}
+	return nil
+}
+
+func (c *ManualClock) removeLocked(t *manualTimer) bool {
+	for i, current := range c.timers {
+		if current == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	return t.clock.removeLocked(t)
+}
@@ -0,0 +1,45 @@
+package actor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@never"} {
+		_, err := actor.ParseCron(expr)
+		assert.ErrorIs(t, err, actor.ErrCronExpressionInvalid, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2025, time.March, 10, 8, 7, 30, 0, time.UTC) // monday
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.March, 10, 8, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.March, 10, 8, 15, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"30 6 * * *", time.Date(2025, time.March, 11, 6, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2025, time.March, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2025, time.March, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		cron, err := actor.ParseCron(c.expr)
+		assert.NoError(t, err, c.expr)
+		assert.Equal(t, c.expected, cron.Next(from), c.expr)
+	}
+
+	never, err := actor.ParseCron("0 0 30 2 *")
+	assert.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero(), "an expression that never matches has no next activation")
+}
@@ -0,0 +1,181 @@
+package actor
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DeadLetterAddress is the well-known address of the dead letter office of every system
+var DeadLetterAddress = NewAddress("system", "dead-letters")
+
+// DefaultDeadLetterCapacity is the number of dead letters retained for inspection and replay
+const DefaultDeadLetterCapacity = 1000
+
+// DeadLetter wraps a message that can't be delivered or processed, with the reason
+type DeadLetter struct {
+	Message Message
+	Reason  error
+	At      time.Time
+}
+
+// SubscribeDeadLetters is sent to DeadLetterAddress to receive every new dead letter at the sender address
+type SubscribeDeadLetters struct{}
+
+// UnsubscribeDeadLetters is sent to DeadLetterAddress to stop receiving dead letters at the sender address
+type UnsubscribeDeadLetters struct{}
+
+// DeadLetterOffice is the state processor of the dead letter actor of a system: it counts the dead letters per reason,
+// retains the latest ones for inspection and replay and forwards them to the subscribers
+type DeadLetterOffice struct {
+	system      *ActorSystem
+	mutex       sync.RWMutex
+	capacity    int
+	letters     []DeadLetter
+	counters    map[string]uint64
+	subscribers []*Address
+}
+
+func newDeadLetterOffice(s *ActorSystem, capacity int) *DeadLetterOffice {
+	return &DeadLetterOffice{
+		system:   s,
+		capacity: capacity,
+		letters:  make([]DeadLetter, 0),
+		counters: make(map[string]uint64),
+	}
+}
+
+func (o *DeadLetterOffice) Process(msg Message) {
+	switch body := msg.Body.(type) {
+	case DeadLetter:
+		o.record(body)
+		o.notify(body)
+		o.system.events.Publish(TopicDeadLetter, body)
+	case SubscribeDeadLetters:
+		o.Subscribe(msg.From)
+	case UnsubscribeDeadLetters:
+		o.Unsubscribe(msg.From)
+	default:
+		slog.Warn("dead letter office received an unknown message", slog.String("msg", msg.String()))
+	}
+}
+
+func (o *DeadLetterOffice) record(letter DeadLetter) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.counters[letter.Reason.Error()]++
+	if o.capacity <= 0 {
+		return
+	}
+	if len(o.letters) >= o.capacity {
+		o.letters = o.letters[1:]
+	}
+	o.letters = append(o.letters, letter)
+}
+
+// notify forwards the dead letter to the subscribers: a subscriber that can't be reached does not produce a new dead letter
+func (o *DeadLetterOffice) notify(letter DeadLetter) {
+	o.mutex.RLock()
+	subscribers := append([]*Address{}, o.subscribers...)
+	o.mutex.RUnlock()
+
+	for _, sub := range subscribers {
+		err := o.system.deliver(NewMessage(sub, DeadLetterAddress, letter))
+		if err != nil {
+			slog.Warn("dead letter subscriber not reachable", slog.String("subscriber", sub.String()), slog.String("err", err.Error()))
+		}
+	}
+}
+
+// Subscribe adds an actor receiving every new dead letter as message body
+func (o *DeadLetterOffice) Subscribe(address *Address) {
+	if address == nil {
+		return
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for _, sub := range o.subscribers {
+		if sub.IsEqual(address) {
+			return
+		}
+	}
+	o.subscribers = append(o.subscribers, address)
+}
+
+func (o *DeadLetterOffice) Unsubscribe(address *Address) {
+	if address == nil {
+		return
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for i, sub := range o.subscribers {
+		if sub.IsEqual(address) {
+			o.subscribers = append(o.subscribers[:i], o.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Counters returns the number of dead letters per reason
+func (o *DeadLetterOffice) Counters() map[string]uint64 {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	counters := make(map[string]uint64, len(o.counters))
+	for reason, count := range o.counters {
+		counters[reason] = count
+	}
+	return counters
+}
+
+// Count returns the number of dead letters with the given reason
+func (o *DeadLetterOffice) Count(reason error) uint64 {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return o.counters[reason.Error()]
+}
+
+// Letters returns the retained dead letters, from the oldest
+func (o *DeadLetterOffice) Letters() []DeadLetter {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	return append([]DeadLetter{}, o.letters...)
+}
+
+// Replay sends again the retained dead letters accepted by filter (all if nil) and removes them from the office.
+// It returns the number of messages delivered: the ones failing again become new dead letters.
+func (o *DeadLetterOffice) Replay(filter func(DeadLetter) bool) int {
+	o.mutex.Lock()
+	replay := make([]DeadLetter, 0)
+	kept := make([]DeadLetter, 0, len(o.letters))
+	for _, letter := range o.letters {
+		if filter == nil || filter(letter) {
+			replay = append(replay, letter)
+		} else {
+			kept = append(kept, letter)
+		}
+	}
+	o.letters = kept
+	o.mutex.Unlock()
+
+	delivered := 0
+	for _, letter := range replay {
+		if o.system.SendMessage(letter.Message) == nil {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func (o *DeadLetterOffice) Shutdown() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.subscribers = nil
+}
+
+func (o *DeadLetterOffice) GetState() any {
+	return o.Counters()
+}
@@ -0,0 +1,160 @@
+package actor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// letterCollector forwards the dead letters it receives to its channel
+type letterCollector struct {
+	letters chan actor.DeadLetter
+}
+
+func newLetterCollector() *letterCollector {
+	return &letterCollector{letters: make(chan actor.DeadLetter, 10)}
+}
+
+func (c *letterCollector) Process(msg actor.Message) {
+	if letter, ok := msg.Body.(actor.DeadLetter); ok {
+		c.letters <- letter
+	}
+}
+
+func (c *letterCollector) Shutdown() {}
+
+func (c *letterCollector) GetState() any {
+	return nil
+}
+
+func receiveLetter(t *testing.T, c <-chan actor.DeadLetter) actor.DeadLetter {
+	select {
+	case letter := <-c:
+		return letter
+	case <-time.After(time.Second):
+		t.Fatal("dead letter not received in time")
+	}
+	return actor.DeadLetter{}
+}
+
+func TestDeadLetterOnActorNotFound(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	collector := newLetterCollector()
+	collectorAddress := actor.NewAddress("local", "collector")
+	_, err := s.RegisterActor(collectorAddress, collector)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(actor.DeadLetterAddress, collectorAddress, actor.SubscribeDeadLetters{})))
+	assert.Equal(t, 1, s.NumActors(), "dead letter office is not counted as actor")
+
+	missing := actor.NewAddress("local", "missing")
+	err = s.SendMessage(actor.NewMessage(missing, nil, "lost"))
+	assert.Equal(t, actor.ErrActorNotFound, err)
+
+	letter := receiveLetter(t, collector.letters)
+	assert.Equal(t, actor.ErrActorNotFound, letter.Reason)
+	assert.Equal(t, "lost", letter.Message.Body)
+	assert.Equal(t, uint64(1), s.DeadLetters().Count(actor.ErrActorNotFound))
+	assert.Len(t, s.DeadLetters().Letters(), 1)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(actor.DeadLetterAddress, collectorAddress, actor.UnsubscribeDeadLetters{})))
+	assert.Eventually(t, func() bool {
+		s.SendMessage(actor.NewMessage(missing, nil, "lost again"))
+		return s.DeadLetters().Count(actor.ErrActorNotFound) >= 2
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case <-collector.letters:
+		t.Error("unsubscribed actor must not receive dead letters")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestDeadLetterReplay(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	address := actor.NewAddress("local", "late")
+	s.SendMessage(actor.NewMessage(address, nil, "first"))
+	s.SendMessage(actor.NewMessage(address, nil, "second"))
+	s.SendMessage(actor.NewMessage(actor.NewAddress("local", "other"), nil, "third"))
+	assert.Eventually(t, func() bool {
+		return len(s.DeadLetters().Letters()) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	processor := &legacyProcessor{contexts: make(chan context.Context, 10)}
+	_, err := s.RegisterActor(address, processor)
+	assert.NoError(t, err)
+
+	delivered := s.DeadLetters().Replay(func(letter actor.DeadLetter) bool {
+		return letter.Message.To.IsEqual(address)
+	})
+	assert.Equal(t, 2, delivered)
+	receiveContext(t, processor.contexts)
+	receiveContext(t, processor.contexts)
+	assert.Len(t, s.DeadLetters().Letters(), 1, "not replayed letters are retained")
+	assert.Equal(t, uint64(3), s.DeadLetters().Count(actor.ErrActorNotFound))
+}
+
+func TestDeadLetterCapacity(t *testing.T) {
+	s, _ := actor.NewActorSystem(actor.WithDeadLetterCapacity(2))
+	defer s.Shutdown()
+
+	missing := actor.NewAddress("local", "missing")
+	for _, body := range []string{"1", "2", "3"} {
+		s.SendMessage(actor.NewMessage(missing, nil, body))
+	}
+	assert.Eventually(t, func() bool {
+		return s.DeadLetters().Count(actor.ErrActorNotFound) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	letters := s.DeadLetters().Letters()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, "2", letters[0].Message.Body, "oldest letters are discarded first")
+	assert.Equal(t, "3", letters[1].Message.Body)
+}
+
+func TestDeadLetterReasons(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	_, err := s.RegisterActor(actor.DeadLetterAddress, newLetterCollector())
+	assert.Equal(t, actor.ErrActorAddressAlreadyRegistered, err)
+
+	r := actor.NewRouter()
+	routerActor, err := s.RegisterActor(actor.NewAddress("local", "router"), r)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(routerActor.GetAddress(), nil, "unhandled")))
+
+	closed, err := s.RegisterActor(actor.NewAddress("local", "closed"), newLetterCollector())
+	assert.NoError(t, err)
+	closed.Deactivate()
+	assert.Equal(t, actor.ErrInboxClosed, s.SendMessage(actor.NewMessage(closed.GetAddress(), nil, "closed")))
+
+	assert.Equal(t, actor.ErrOutboundServiceNotEnabled, s.SendMessage(actor.NewMessage(actor.NewOutboundAddress("app2", "local", "actor"), nil, "remote")))
+
+	assert.Eventually(t, func() bool {
+		counters := s.DeadLetters().Counters()
+		return counters[actor.ErrMessageNotHandled.Error()] == 1 &&
+			counters[actor.ErrInboxClosed.Error()] == 1 &&
+			counters[actor.ErrOutboundServiceNotEnabled.Error()] == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDeadLetterOnUnknownOutboundType(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	app1, err := actor.NewActorSystem(actor.WithTransport("app1", network.NewTransport(), remoteRegistry))
+	assert.NoError(t, err)
+	defer app1.Shutdown()
+	app2, err := actor.NewActorSystem(actor.WithTransport("app2", network.NewTransport(), actor.EnvelopePayloadTypeRegistry{}))
+	assert.NoError(t, err)
+	defer app2.Shutdown()
+
+	assert.NoError(t, app1.SendMessage(actor.NewMessage(actor.NewOutboundAddress("app2", "local", "actor"), nil, RemoteGreeting{Text: "hello"})))
+	assert.Eventually(t, func() bool {
+		return app2.DeadLetters().Count(actor.ErrOutboundPayloadTypeNotRegistered) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -2,15 +2,27 @@ package actor
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"reflect"
-
-	"github.com/nats-io/nats.go"
+	"strings"
 )
 
+var ErrOutboundPayloadTypeNotRegistered = errors.New("outbound payload type not found in registry")
+
 type OutboundEvenlope struct {
 	BodyType string `json:"bodyType"`
 	RawBody  []byte `json:"rawBody"`
+	// From is the address of the sender, reachable as outbound address by the receiving app
+	From *OutboundEnvelopeAddress `json:"from,omitempty"`
+	// ReplyTo is the subject where the response of a message with response is published
+	ReplyTo string `json:"replyTo,omitempty"`
+	// CorrelationID matches a response with its request
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Timeout in seconds the sender waits for the response
+	Timeout int `json:"timeout,omitempty"`
+	// Error is set on a response when the remote actor can not be asked
+	Error string `json:"error,omitempty"`
 }
 
 func NewOutboundEnvelope(body any, bodyType string) (OutboundEvenlope, error) {
@@ -26,10 +38,76 @@ func NewOutboundEnvelope(body any, bodyType string) (OutboundEvenlope, error) {
 	}, nil
 }
 
+// OutboundEnvelopeAddress is the serializable form of an outbound address
+type OutboundEnvelopeAddress struct {
+	OutboundArea string `json:"outboundArea"`
+	Area         string `json:"area"`
+	ID           string `json:"id"`
+}
+
+// NewOutboundEnvelopeAddress returns the address of a local actor as seen from a remote app, nil if the address is nil
+func NewOutboundEnvelopeAddress(outboundArea string, address *Address) *OutboundEnvelopeAddress {
+	if address == nil {
+		return nil
+	}
+	if address.IsOutbound() {
+		outboundArea = address.outboundArea
+	}
+	return &OutboundEnvelopeAddress{
+		OutboundArea: outboundArea,
+		Area:         address.area,
+		ID:           address.id,
+	}
+}
+
+// Address returns the outbound address to reach the sender from the receiving app
+func (addr *OutboundEnvelopeAddress) Address() *Address {
+	if addr == nil {
+		return nil
+	}
+	return NewOutboundAddress(addr.OutboundArea, addr.Area, addr.ID)
+}
+
+// EnvelopePayloadTypeRegistry maps the body type name, as reported by reflect (es. "main.MsgBody" or "*main.MsgBody"), to the type used to decode it
 type EnvelopePayloadTypeRegistry map[string]reflect.Type
 
+// Decode decodes the raw body to a value of the registered type: a value for a value type and a pointer for a pointer type.
+// A pointer body type not registered ("*main.MsgBody") is decoded as pointer to the registered value type ("main.MsgBody"), so the receiver gets the same shape sent.
+func (registry EnvelopePayloadTypeRegistry) Decode(bodyType string, rawBody []byte) (any, error) {
+	payloadType := registry[bodyType]
+	asPointer := false
+	if payloadType == nil && strings.HasPrefix(bodyType, "*") {
+		payloadType = registry[bodyType[1:]]
+		asPointer = true
+	}
+	if payloadType == nil {
+		return nil, ErrOutboundPayloadTypeNotRegistered
+	}
+
+	if payloadType.Kind() == reflect.Pointer {
+		payloadType = payloadType.Elem()
+		asPointer = true
+	}
+
+	payload := reflect.New(payloadType)
+	err := json.Unmarshal(rawBody, payload.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	if asPointer {
+		return payload.Interface(), nil
+	}
+	return payload.Elem().Interface(), nil
+}
+
 type OutboundOptions struct {
-	natsConnection *nats.Conn
-	typeRegistry   EnvelopePayloadTypeRegistry
-	outboundArea   string
+	transport    Transport
+	typeRegistry EnvelopePayloadTypeRegistry
+	outboundArea string
+	// nats is the configuration of the NATS connection dialed on start, when the transport is not given
+	nats *natsConfig
+	// ownsTransport reports if the transport has been created by the system, so it must be closed on shutdown
+	ownsTransport bool
+	subscription  TransportSubscription
 }
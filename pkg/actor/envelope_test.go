@@ -3,6 +3,7 @@ package actor_test
 import (
 	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/pix303/cinecity/pkg/actor"
@@ -127,3 +128,86 @@ func TestNewOutboundEnvelopeMarshalError(t *testing.T) {
 	assert.Equal(t, "", envelope.BodyType)
 	assert.Empty(t, envelope.RawBody)
 }
+
+func TestOutboundEnvelopeRequestFields(t *testing.T) {
+	envelope, err := actor.NewOutboundEnvelope(TestPayload{Code: "req", Pcs: 1}, "test.payload")
+	assert.NoError(t, err)
+	envelope.ReplyTo = "_INBOX.reply"
+	envelope.CorrelationID = "correlation-1"
+	envelope.Timeout = 5
+
+	data, err := json.Marshal(envelope)
+	assert.NoError(t, err)
+
+	var decoded actor.OutboundEvenlope
+	err = json.Unmarshal(data, &decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, envelope, decoded)
+}
+
+func TestOutboundEnvelopeWithoutRequestFields(t *testing.T) {
+	envelope, err := actor.NewOutboundEnvelope("test", "string")
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(envelope)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "replyTo", "reply subject must be omitted for fire and forget messages")
+	assert.NotContains(t, string(data), "correlationId", "correlation id must be omitted for fire and forget messages")
+}
+
+func TestEnvelopePayloadTypeRegistryDecodeValue(t *testing.T) {
+	registry := actor.EnvelopePayloadTypeRegistry{
+		"actor_test.TestPayload": reflect.TypeOf(TestPayload{}),
+	}
+	envelope, err := actor.NewOutboundEnvelope(TestPayload{Code: "value", Pcs: 3}, reflect.TypeOf(TestPayload{}).String())
+	assert.NoError(t, err)
+
+	payload, err := registry.Decode(envelope.BodyType, envelope.RawBody)
+	assert.NoError(t, err)
+	assert.Equal(t, TestPayload{Code: "value", Pcs: 3}, payload, "value type must be delivered as value")
+}
+
+func TestEnvelopePayloadTypeRegistryDecodePointer(t *testing.T) {
+	registry := actor.EnvelopePayloadTypeRegistry{
+		"*actor_test.TestPayload": reflect.TypeOf(&TestPayload{}),
+	}
+	body := &TestPayload{Code: "pointer", Pcs: 4}
+	envelope, err := actor.NewOutboundEnvelope(body, reflect.TypeOf(body).String())
+	assert.NoError(t, err)
+
+	payload, err := registry.Decode(envelope.BodyType, envelope.RawBody)
+	assert.NoError(t, err)
+	assert.Equal(t, body, payload, "pointer type must be delivered as pointer")
+}
+
+func TestEnvelopePayloadTypeRegistryDecodePointerOfRegisteredValue(t *testing.T) {
+	registry := actor.EnvelopePayloadTypeRegistry{
+		"actor_test.TestPayload": reflect.TypeOf(TestPayload{}),
+	}
+	body := &TestPayload{Code: "pointer", Pcs: 5}
+	envelope, err := actor.NewOutboundEnvelope(body, reflect.TypeOf(body).String())
+	assert.NoError(t, err)
+
+	payload, err := registry.Decode(envelope.BodyType, envelope.RawBody)
+	assert.NoError(t, err)
+	assert.Equal(t, body, payload, "pointer body must be delivered as pointer of the registered value type")
+}
+
+func TestEnvelopePayloadTypeRegistryDecodeUnknownType(t *testing.T) {
+	registry := actor.EnvelopePayloadTypeRegistry{}
+	_, err := registry.Decode("unknown.Type", []byte("{}"))
+	assert.Equal(t, actor.ErrOutboundPayloadTypeNotRegistered, err)
+}
+
+func TestOutboundEnvelopeAddress(t *testing.T) {
+	local := actor.NewAddress("local", "sender")
+	envelopeAddress := actor.NewOutboundEnvelopeAddress("app2", local)
+	assert.Equal(t, "cinecity.app2.local.sender", envelopeAddress.Address().String())
+
+	remote := actor.NewOutboundAddress("app3", "local", "other")
+	assert.Equal(t, "cinecity.app3.local.other", actor.NewOutboundEnvelopeAddress("app2", remote).Address().String())
+
+	assert.Nil(t, actor.NewOutboundEnvelopeAddress("app2", nil))
+	var nilAddress *actor.OutboundEnvelopeAddress
+	assert.Nil(t, nilAddress.Address())
+}
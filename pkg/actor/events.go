@@ -0,0 +1,171 @@
+package actor
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Topics of the lifecycle events published by the runtime on the event stream of the system
+const (
+	TopicActorRegistered = "system.actor.registered"
+	TopicActorDropped    = "system.actor.dropped"
+	TopicDeadLetter      = "system.dead-letter"
+	TopicRemoteLinkUp    = "system.remote.up"
+	TopicRemoteLinkDown  = "system.remote.down"
+)
+
+// ActorRegistered is published on TopicActorRegistered when an actor is registered and activated
+type ActorRegistered struct {
+	Address *Address
+}
+
+// ActorDropped is published on TopicActorDropped when an actor is dropped
+type ActorDropped struct {
+	Address *Address
+}
+
+// RemoteLinkUp is published on TopicRemoteLinkUp when the outbound service is started and when the transport restores the link
+type RemoteLinkUp struct {
+	Area string
+}
+
+// RemoteLinkDown is published on TopicRemoteLinkDown when the transport loses the link with the other apps
+type RemoteLinkDown struct {
+	Area   string
+	Reason error
+}
+
+// EventStream is the event bus of a system: any actor can subscribe to the events published on topics, with the wildcards
+// "*" for one token and ">" for the remaining ones, or to the events with a body type. The runtime publishes the lifecycle
+// events on the "system." topics, the dead letters included as DeadLetter bodies.
+// Events are delivered as messages with the event as body and without the topic, so subscribers tell the events apart by
+// the type of the body; an actor is unsubscribed when it is dropped.
+type EventStream struct {
+	system        *ActorSystem
+	mutex         sync.RWMutex
+	subscriptions []*eventSubscription
+}
+
+// Filter selects the message bodies delivered to a subscription
+type Filter func(body any) bool
+
+// BodyOf returns a filter accepting the bodies of type T, or implementing T if it is an interface
+func BodyOf[T any]() Filter {
+	return func(body any) bool {
+		_, ok := body.(T)
+		return ok
+	}
+}
+
+// TopicMatcher selects the messages published on the topics matching its patterns, all the messages if none,
+// and accepted by its filter, if any
+type TopicMatcher struct {
+	Patterns []string
+	Filter   Filter
+}
+
+// Matches reports if the body published on the topic is selected, see MatchSubject for the patterns
+func (m TopicMatcher) Matches(topic string, body any) bool {
+	if len(m.Patterns) > 0 {
+		matched := false
+		for _, pattern := range m.Patterns {
+			if MatchSubject(pattern, topic) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return m.Filter == nil || m.Filter(body)
+}
+
+// eventSubscription is an actor interested in the events selected by its matcher
+type eventSubscription struct {
+	address *Address
+	matcher TopicMatcher
+}
+
+func newEventStream(s *ActorSystem) *EventStream {
+	return &EventStream{system: s}
+}
+
+// Events returns the event stream of the system
+func (s *ActorSystem) Events() *EventStream {
+	return s.events
+}
+
+// Events returns the event stream of the system of the actor
+func (a *Actor) Events() *EventStream {
+	return a.system.events
+}
+
+// Subscribe delivers to the actor at address the events published on the topics matching the patterns, all the events if none
+func (e *EventStream) Subscribe(address *Address, patterns ...string) {
+	e.add(address, TopicMatcher{Patterns: append([]string{}, patterns...)})
+}
+
+// SubscribeEvent delivers to the actor at address the events with body of type T, or implementing T if it is an interface, published on any topic
+func SubscribeEvent[T any](e *EventStream, address *Address) {
+	e.add(address, TopicMatcher{Filter: BodyOf[T]()})
+}
+
+func (e *EventStream) add(address *Address, matcher TopicMatcher) {
+	if address == nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.subscriptions = append(e.subscriptions, &eventSubscription{address: address, matcher: matcher})
+}
+
+// Unsubscribe removes all the subscriptions of the actor at address
+func (e *EventStream) Unsubscribe(address *Address) {
+	if address == nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	kept := e.subscriptions[:0]
+	for _, sub := range e.subscriptions {
+		if !sub.address.IsEqual(address) {
+			kept = append(kept, sub)
+		}
+	}
+	clear(e.subscriptions[len(kept):])
+	e.subscriptions = kept
+}
+
+// NumSubscriptions returns the number of subscriptions to the stream
+func (e *EventStream) NumSubscriptions() int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return len(e.subscriptions)
+}
+
+// Publish sends the event once to every actor with a subscription accepting it, and returns the number of actors reached.
+// The topic only selects the subscribers: it is not delivered with the event. A subscriber that can't be reached does not
+// produce a dead letter.
+func (e *EventStream) Publish(topic string, event any) int {
+	e.mutex.RLock()
+	subscribers := make([]*Address, 0, len(e.subscriptions))
+	for _, sub := range e.subscriptions {
+		if sub.matcher.Matches(topic, event) {
+			subscribers = appendAddress(subscribers, sub.address)
+		}
+	}
+	e.mutex.RUnlock()
+
+	delivered := 0
+	for _, address := range subscribers {
+		err := e.system.deliver(NewMessage(address, nil, event))
+		if err != nil {
+			slog.Debug("event subscriber not reachable", slog.String("subscriber", address.String()), slog.String("topic", topic), slog.String("err", err.Error()))
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
@@ -0,0 +1,146 @@
+package actor_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventCollector reports the events it receives
+type eventCollector struct {
+	events chan any
+}
+
+func (c *eventCollector) Process(msg actor.Message) {
+	c.events <- msg.Body
+}
+
+func (c *eventCollector) Shutdown() {}
+
+func (c *eventCollector) GetState() any {
+	return nil
+}
+
+func setupEventCollector(t *testing.T, s *actor.ActorSystem, id string) (*actor.Actor, chan any) {
+	collector := &eventCollector{events: make(chan any, 10)}
+	a, err := s.RegisterActor(actor.NewAddress("events", id), collector)
+	assert.NoError(t, err)
+	return a, collector.events
+}
+
+func receiveEvent(t *testing.T, c <-chan any) any {
+	select {
+	case event := <-c:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("event not received in time")
+	}
+	return nil
+}
+
+func TestEventStreamLifecycleEvents(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	subscriber, events := setupEventCollector(t, s, "lifecycle")
+	subscriber.Events().Subscribe(subscriber.GetAddress(), "system.actor.*")
+
+	address := actor.NewAddress("local", "observed")
+	a, err := s.RegisterActor(address, newLetterCollector())
+	assert.NoError(t, err)
+	registered := receiveEvent(t, events).(actor.ActorRegistered)
+	assert.True(t, registered.Address.IsEqual(address))
+
+	a.Drop()
+	dropped := receiveEvent(t, events).(actor.ActorDropped)
+	assert.True(t, dropped.Address.IsEqual(address))
+
+	assert.Equal(t, 1, s.Events().NumSubscriptions())
+	subscriber.Drop()
+	assert.Equal(t, 0, s.Events().NumSubscriptions(), "dropped actor is unsubscribed")
+}
+
+func TestEventStreamByBodyType(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	subscriber, events := setupEventCollector(t, s, "letters")
+	actor.SubscribeEvent[actor.DeadLetter](s.Events(), subscriber.GetAddress())
+
+	_, err := s.RegisterActor(actor.NewAddress("local", "other"), newLetterCollector())
+	assert.NoError(t, err)
+	missing := actor.NewAddress("local", "missing")
+	assert.ErrorIs(t, s.SendMessage(actor.NewMessage(missing, nil, "lost")), actor.ErrActorNotFound)
+
+	letter := receiveEvent(t, events).(actor.DeadLetter)
+	assert.Equal(t, "lost", letter.Message.Body)
+	assert.Equal(t, actor.ErrActorNotFound, letter.Reason)
+	assert.Empty(t, events, "events of other types are not delivered")
+}
+
+type PriceChanged struct {
+	Code string
+}
+
+func TestEventStreamPublishOnTopics(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	prices, pricesEvents := setupEventCollector(t, s, "prices")
+	all, allEvents := setupEventCollector(t, s, "all")
+	s.Events().Subscribe(prices.GetAddress(), "prices.*")
+	s.Events().Subscribe(prices.GetAddress(), "prices.>")
+	actor.SubscribeEvent[PriceChanged](s.Events(), all.GetAddress())
+
+	assert.Equal(t, 2, s.Events().Publish("prices.eu", PriceChanged{Code: "A"}))
+	assert.Equal(t, 0, s.Events().Publish("stock.eu", "no subscribers"))
+	assert.Equal(t, 1, s.Events().Publish("prices.eu.rome", "not a price"))
+
+	assert.Equal(t, PriceChanged{Code: "A"}, receiveEvent(t, pricesEvents))
+	assert.Equal(t, "not a price", receiveEvent(t, pricesEvents), "an event is delivered once per subscriber")
+	assert.Equal(t, PriceChanged{Code: "A"}, receiveEvent(t, allEvents))
+
+	s.Events().Unsubscribe(prices.GetAddress())
+	assert.Equal(t, 1, s.Events().Publish("prices.eu", PriceChanged{Code: "B"}))
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, pricesEvents)
+}
+
+func TestEventStreamRemoteLinkEvents(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	transport := network.NewTransport()
+	s, err := actor.NewActorSystem(actor.WithTransport("app1", transport, remoteRegistry))
+	assert.NoError(t, err)
+	defer s.Shutdown()
+
+	subscriber, events := setupEventCollector(t, s, "remote")
+	s.Events().Subscribe(subscriber.GetAddress(), "system.remote.*")
+
+	linkErr := errors.New("connection reset")
+	transport.LoseLink(linkErr)
+	down := receiveEvent(t, events).(actor.RemoteLinkDown)
+	assert.Equal(t, "app1", down.Area)
+	assert.ErrorIs(t, down.Reason, actor.ErrTransportLinkLost)
+	assert.ErrorIs(t, down.Reason, linkErr)
+
+	transport.RestoreLink()
+	assert.Equal(t, actor.RemoteLinkUp{Area: "app1"}, receiveEvent(t, events))
+}
+
+func TestTopicMatcher(t *testing.T) {
+	assert.True(t, actor.TopicMatcher{}.Matches("prices.eu", "anything"), "no patterns and no filter select everything")
+
+	topics := actor.TopicMatcher{Patterns: []string{"prices.*", "stock.>"}}
+	assert.True(t, topics.Matches("prices.eu", nil))
+	assert.True(t, topics.Matches("stock.eu.rome", nil))
+	assert.False(t, topics.Matches("prices.eu.rome", nil))
+	assert.False(t, topics.Matches("", nil), "a message without topic does not match patterns")
+
+	prices := actor.TopicMatcher{Patterns: []string{"prices.*"}, Filter: actor.BodyOf[PriceChanged]()}
+	assert.True(t, prices.Matches("prices.eu", PriceChanged{Code: "A"}))
+	assert.False(t, prices.Matches("prices.eu", "not a price"))
+	assert.False(t, prices.Matches("stock.eu", PriceChanged{Code: "A"}))
+}
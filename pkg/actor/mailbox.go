@@ -0,0 +1,353 @@
+package actor
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrMailboxFull = errors.New("actor mailbox is full")
+)
+
+// DefaultMailboxCapacity is the capacity of the mailbox created when none is configured
+const DefaultMailboxCapacity = 100
+
+// Mailbox stores the messages waiting to be processed by an actor
+type Mailbox interface {
+	// Post enqueues the message; when the mailbox is full it blocks, drops or fails depending on its overflow policy
+	Post(msg Message) error
+	// Receive waits for the next message; it returns false when stop is closed or the mailbox is closed and empty
+	Receive(stop <-chan struct{}) (Message, bool)
+	// Close rejects new messages and unblocks the waiting senders; pending messages can still be received
+	Close()
+	Len() int
+	Metrics() MailboxMetrics
+}
+
+// MailboxFactory creates a new mailbox for every actor
+type MailboxFactory func() Mailbox
+
+// MailboxMetrics reports the current depth and the counters of a mailbox
+type MailboxMetrics struct {
+	Depth     int
+	MaxDepth  int
+	Posted    uint64
+	Dropped   uint64
+	Rejected  uint64
+	Delivered uint64
+}
+
+// OverflowPolicy defines the behaviour of a bounded mailbox when it is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the sender until there is room for the message
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the message being posted
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest message in the mailbox to make room for the new one
+	OverflowDropOldest
+	// OverflowError rejects the message returning ErrMailboxFull
+	OverflowError
+)
+
+func (policy OverflowPolicy) String() string {
+	switch policy {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// messageQueue is the storage of a mailbox
+type messageQueue interface {
+	push(msg Message)
+	pop() Message
+	len() int
+}
+
+type fifoQueue struct {
+	items []Message
+}
+
+func (q *fifoQueue) push(msg Message) {
+	q.items = append(q.items, msg)
+}
+
+func (q *fifoQueue) pop() Message {
+	msg := q.items[0]
+	q.items[0] = EmptyMessage
+	q.items = q.items[1:]
+	return msg
+}
+
+func (q *fifoQueue) len() int {
+	return len(q.items)
+}
+
+type priorityItem struct {
+	msg Message
+	seq uint64
+}
+
+// priorityQueue pops the message with highest priority first and, with same priority, the oldest one
+type priorityQueue struct {
+	items []priorityItem
+	seq   uint64
+}
+
+func (q *priorityQueue) Len() int { return len(q.items) }
+
+func (q *priorityQueue) Less(i, j int) bool {
+	if q.items[i].msg.Priority != q.items[j].msg.Priority {
+		return q.items[i].msg.Priority > q.items[j].msg.Priority
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q *priorityQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *priorityQueue) Push(x any) { q.items = append(q.items, x.(priorityItem)) }
+
+func (q *priorityQueue) Pop() any {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items = q.items[:n-1]
+	return item
+}
+
+func (q *priorityQueue) push(msg Message) {
+	q.seq++
+	heap.Push(q, priorityItem{msg: msg, seq: q.seq})
+}
+
+func (q *priorityQueue) pop() Message {
+	return heap.Pop(q).(priorityItem).msg
+}
+
+func (q *priorityQueue) len() int {
+	return len(q.items)
+}
+
+// queueMailbox implements all the mailbox types over a message queue: capacity 0 means unbounded
+type queueMailbox struct {
+	mutex    sync.Mutex
+	queue    messageQueue
+	capacity int
+	policy   OverflowPolicy
+	metrics  MailboxMetrics
+	closed   bool
+	waiting  int
+	notify   chan struct{}
+	space    chan struct{}
+	closedCh chan struct{}
+}
+
+func newQueueMailbox(queue messageQueue, capacity int, policy OverflowPolicy) *queueMailbox {
+	return &queueMailbox{
+		queue:    queue,
+		capacity: capacity,
+		policy:   policy,
+		notify:   make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+		closedCh: make(chan struct{}),
+	}
+}
+
+// NewUnboundedMailbox creates a FIFO mailbox without capacity limits: senders never block
+func NewUnboundedMailbox() Mailbox {
+	return newQueueMailbox(&fifoQueue{}, 0, OverflowBlock)
+}
+
+// NewBoundedMailbox creates a FIFO mailbox that holds at most capacity messages and applies the policy when full
+func NewBoundedMailbox(capacity int, policy OverflowPolicy) Mailbox {
+	if capacity <= 0 {
+		capacity = DefaultMailboxCapacity
+	}
+	return newQueueMailbox(&fifoQueue{}, capacity, policy)
+}
+
+// NewPriorityMailbox creates an unbounded mailbox delivering first the messages with higher Priority
+func NewPriorityMailbox() Mailbox {
+	return newQueueMailbox(&priorityQueue{}, 0, OverflowBlock)
+}
+
+// NewBoundedPriorityMailbox creates a priority mailbox that holds at most capacity messages and applies the policy when full;
+// with OverflowDropOldest the message with lowest priority is discarded
+func NewBoundedPriorityMailbox(capacity int, policy OverflowPolicy) Mailbox {
+	if capacity <= 0 {
+		capacity = DefaultMailboxCapacity
+	}
+	return newQueueMailbox(&priorityQueue{}, capacity, policy)
+}
+
+func defaultMailbox() Mailbox {
+	return NewBoundedMailbox(DefaultMailboxCapacity, OverflowBlock)
+}
+
+func (mb *queueMailbox) Post(msg Message) error {
+	mb.mutex.Lock()
+	for {
+		if mb.closed {
+			mb.metrics.Rejected++
+			mb.mutex.Unlock()
+			return ErrInboxClosed
+		}
+
+		if mb.capacity == 0 || mb.queue.len() < mb.capacity {
+			break
+		}
+
+		switch mb.policy {
+		case OverflowDropNewest:
+			mb.metrics.Dropped++
+			mb.mutex.Unlock()
+			return nil
+		case OverflowDropOldest:
+			mb.dropOldest()
+		case OverflowError:
+			mb.metrics.Rejected++
+			mb.mutex.Unlock()
+			return ErrMailboxFull
+		default:
+			mb.waiting++
+			mb.mutex.Unlock()
+			select {
+			case <-mb.space:
+			case <-mb.closedCh:
+			}
+			mb.mutex.Lock()
+			mb.waiting--
+		}
+	}
+
+	mb.enqueue(msg)
+	if mb.waiting > 0 && mb.queue.len() < mb.capacity {
+		wakeUp(mb.space)
+	}
+	mb.mutex.Unlock()
+
+	wakeUp(mb.notify)
+	return nil
+}
+
+// forcePoster is implemented by the mailboxes of the package, that can enqueue a message of the actor to itself beyond the capacity
+type forcePoster interface {
+	forcePost(msg Message) error
+}
+
+// forcePost enqueues the message also when the mailbox is full, without applying the overflow policy
+func (mb *queueMailbox) forcePost(msg Message) error {
+	mb.mutex.Lock()
+	if mb.closed {
+		mb.metrics.Rejected++
+		mb.mutex.Unlock()
+		return ErrInboxClosed
+	}
+	mb.enqueue(msg)
+	mb.mutex.Unlock()
+
+	wakeUp(mb.notify)
+	return nil
+}
+
+// enqueue pushes the message and updates the metrics; the caller must hold the lock
+func (mb *queueMailbox) enqueue(msg Message) {
+	mb.queue.push(msg)
+	mb.metrics.Posted++
+	if depth := mb.queue.len(); depth > mb.metrics.MaxDepth {
+		mb.metrics.MaxDepth = depth
+	}
+}
+
+// dropOldest discards the first message to be delivered for a FIFO queue and, for a priority queue, the oldest one
+// among the messages with lowest priority
+func (mb *queueMailbox) dropOldest() {
+	mb.metrics.Dropped++
+	if pq, ok := mb.queue.(*priorityQueue); ok {
+		lowest := 0
+		for i, item := range pq.items {
+			current := pq.items[lowest]
+			if item.msg.Priority < current.msg.Priority || (item.msg.Priority == current.msg.Priority && item.seq < current.seq) {
+				lowest = i
+			}
+		}
+		heap.Remove(pq, lowest)
+		return
+	}
+	mb.queue.pop()
+}
+
+func (mb *queueMailbox) Receive(stop <-chan struct{}) (Message, bool) {
+	for {
+		select {
+		case <-stop:
+			return EmptyMessage, false
+		default:
+		}
+
+		mb.mutex.Lock()
+		if mb.queue.len() > 0 {
+			msg := mb.queue.pop()
+			mb.metrics.Delivered++
+			if mb.waiting > 0 {
+				wakeUp(mb.space)
+			}
+			mb.mutex.Unlock()
+			return msg, true
+		}
+		closed := mb.closed
+		mb.mutex.Unlock()
+
+		if closed {
+			return EmptyMessage, false
+		}
+
+		select {
+		case <-mb.notify:
+		case <-mb.closedCh:
+		case <-stop:
+			return EmptyMessage, false
+		}
+	}
+}
+
+func (mb *queueMailbox) Close() {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	if !mb.closed {
+		mb.closed = true
+		close(mb.closedCh)
+	}
+}
+
+func (mb *queueMailbox) Len() int {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+	return mb.queue.len()
+}
+
+func (mb *queueMailbox) Metrics() MailboxMetrics {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	m := mb.metrics
+	m.Depth = mb.queue.len()
+	return m
+}
+
+// wakeUp wakes up a waiting goroutine without blocking if a signal is already pending
+func wakeUp(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,243 @@
+package actor_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func bodies(t *testing.T, mb actor.Mailbox, n int) []any {
+	result := make([]any, 0, n)
+	for range n {
+		msg, ok := mb.Receive(nil)
+		assert.True(t, ok)
+		result = append(result, msg.Body)
+	}
+	return result
+}
+
+func TestUnboundedMailbox(t *testing.T) {
+	mb := actor.NewUnboundedMailbox()
+	for i := range 1000 {
+		assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, i)))
+	}
+	assert.Equal(t, 1000, mb.Len())
+
+	received := bodies(t, mb, 3)
+	assert.Equal(t, []any{0, 1, 2}, received, "messages must be delivered in order")
+
+	m := mb.Metrics()
+	assert.Equal(t, 997, m.Depth)
+	assert.Equal(t, 1000, m.MaxDepth)
+	assert.Equal(t, uint64(1000), m.Posted)
+	assert.Equal(t, uint64(3), m.Delivered)
+}
+
+func TestBoundedMailboxDropNewest(t *testing.T) {
+	mb := actor.NewBoundedMailbox(2, actor.OverflowDropNewest)
+	for i := range 4 {
+		assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, i)))
+	}
+
+	assert.Equal(t, []any{0, 1}, bodies(t, mb, 2))
+	assert.Equal(t, uint64(2), mb.Metrics().Dropped)
+}
+
+func TestBoundedMailboxDropOldest(t *testing.T) {
+	mb := actor.NewBoundedMailbox(2, actor.OverflowDropOldest)
+	for i := range 4 {
+		assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, i)))
+	}
+
+	assert.Equal(t, []any{2, 3}, bodies(t, mb, 2))
+	assert.Equal(t, uint64(2), mb.Metrics().Dropped)
+}
+
+func TestBoundedMailboxError(t *testing.T) {
+	mb := actor.NewBoundedMailbox(1, actor.OverflowError)
+	assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, 1)))
+	assert.Equal(t, actor.ErrMailboxFull, mb.Post(actor.NewMessage(nil, nil, 2)))
+	assert.Equal(t, uint64(1), mb.Metrics().Rejected)
+}
+
+func TestBoundedMailboxBlock(t *testing.T) {
+	mb := actor.NewBoundedMailbox(1, actor.OverflowBlock)
+	assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, 1)))
+
+	var posted atomic.Bool
+	go func() {
+		mb.Post(actor.NewMessage(nil, nil, 2))
+		posted.Store(true)
+	}()
+
+	<-time.After(20 * time.Millisecond)
+	assert.False(t, posted.Load(), "sender must be blocked while the mailbox is full")
+
+	assert.Equal(t, []any{1}, bodies(t, mb, 1))
+	assert.Eventually(t, func() bool {
+		return posted.Load()
+	}, 100*time.Millisecond, 5*time.Millisecond, "sender must be unblocked when there is room")
+	assert.Equal(t, []any{2}, bodies(t, mb, 1))
+}
+
+func TestBoundedMailboxBlockManySenders(t *testing.T) {
+	mb := actor.NewBoundedMailbox(2, actor.OverflowBlock)
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, i)))
+		}()
+	}
+
+	received := bodies(t, mb, 50)
+	wg.Wait()
+	assert.Len(t, received, 50)
+	assert.Equal(t, 0, mb.Len())
+}
+
+func TestBoundedMailboxCloseUnblocksSenders(t *testing.T) {
+	mb := actor.NewBoundedMailbox(1, actor.OverflowBlock)
+	assert.NoError(t, mb.Post(actor.NewMessage(nil, nil, 1)))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- mb.Post(actor.NewMessage(nil, nil, 2))
+	}()
+
+	<-time.After(10 * time.Millisecond)
+	mb.Close()
+	assert.Equal(t, actor.ErrInboxClosed, <-result)
+
+	msg, ok := mb.Receive(nil)
+	assert.True(t, ok, "pending messages must be received after close")
+	assert.Equal(t, 1, msg.Body)
+	_, ok = mb.Receive(nil)
+	assert.False(t, ok, "closed and empty mailbox must stop the receiver")
+}
+
+func TestMailboxReceiveStop(t *testing.T) {
+	mb := actor.NewUnboundedMailbox()
+	stop := make(chan struct{})
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		close(stop)
+	}()
+
+	_, ok := mb.Receive(stop)
+	assert.False(t, ok)
+}
+
+func TestPriorityMailbox(t *testing.T) {
+	mb := actor.NewPriorityMailbox()
+	for i, priority := range []int{0, 5, 1, 5, 10} {
+		msg := actor.NewMessage(nil, nil, i)
+		msg.SetPriority(priority)
+		assert.NoError(t, mb.Post(msg))
+	}
+
+	assert.Equal(t, []any{4, 1, 3, 2, 0}, bodies(t, mb, 5), "higher priority first, fifo with same priority")
+}
+
+func TestBoundedPriorityMailboxDropsLowestPriority(t *testing.T) {
+	mb := actor.NewBoundedPriorityMailbox(2, actor.OverflowDropOldest)
+	for i, priority := range []int{5, 1, 3} {
+		msg := actor.NewMessage(nil, nil, i)
+		msg.SetPriority(priority)
+		assert.NoError(t, mb.Post(msg))
+	}
+
+	assert.Equal(t, []any{0, 2}, bodies(t, mb, 2))
+	assert.Equal(t, uint64(1), mb.Metrics().Dropped)
+}
+
+func TestBoundedPriorityMailboxDropsOldestOfLowestPriority(t *testing.T) {
+	mb := actor.NewBoundedPriorityMailbox(3, actor.OverflowDropOldest)
+	for i, priority := range []int{5, 1, 1, 3} {
+		msg := actor.NewMessage(nil, nil, i)
+		msg.SetPriority(priority)
+		assert.NoError(t, mb.Post(msg))
+	}
+
+	assert.Equal(t, []any{0, 3, 2}, bodies(t, mb, 3), "the older message with lowest priority is evicted")
+	assert.Equal(t, uint64(1), mb.Metrics().Dropped)
+}
+
+func TestActorWithMailbox(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	address := actor.NewAddress("test", "mailbox")
+	counter := &atomic.Int64{}
+	a, err := s.RegisterActor(address, &countingProcessor{counter}, actor.WithMailbox(actor.NewUnboundedMailbox()))
+	assert.NoError(t, err)
+
+	for range 500 {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, "hello")))
+	}
+
+	assert.Eventually(t, func() bool {
+		return counter.Load() == 500
+	}, time.Second, 10*time.Millisecond)
+	m := a.MailboxMetrics()
+	assert.Equal(t, uint64(500), m.Posted)
+	assert.Equal(t, uint64(500), m.Delivered)
+	assert.Equal(t, 0, m.Depth)
+}
+
+type blockingProcessor struct {
+	release chan struct{}
+}
+
+func (p *blockingProcessor) Process(msg actor.Message) {
+	<-p.release
+}
+
+func (p *blockingProcessor) Shutdown() {}
+
+func (p *blockingProcessor) GetState() any {
+	return nil
+}
+
+func TestActorSystemDefaultMailbox(t *testing.T) {
+	s, _ := actor.NewActorSystem(actor.WithDefaultMailbox(func() actor.Mailbox {
+		return actor.NewBoundedMailbox(1, actor.OverflowError)
+	}))
+	defer s.Shutdown()
+
+	address := actor.NewAddress("test", "busy")
+	processor := &blockingProcessor{release: make(chan struct{})}
+	defer close(processor.release)
+	_, err := s.RegisterActor(address, processor)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, "in progress")))
+	assert.Eventually(t, func() bool {
+		return s.SendMessage(actor.NewMessage(address, nil, "queued")) == nil
+	}, 100*time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, actor.ErrMailboxFull, s.SendMessage(actor.NewMessage(address, nil, "overflow")))
+}
+
+func TestPostSelfBypassesFullMailbox(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	address := actor.NewAddress("test", "busy")
+	processor := &blockingProcessor{release: make(chan struct{})}
+	defer close(processor.release)
+	a, err := s.RegisterActor(address, processor, actor.WithMailbox(actor.NewBoundedMailbox(1, actor.OverflowError)))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, "in progress")))
+	assert.Eventually(t, func() bool {
+		return s.SendMessage(actor.NewMessage(address, nil, "queued")) == nil
+	}, 100*time.Millisecond, 5*time.Millisecond)
+	assert.NoError(t, a.PostSelf("result"), "the actor posts to itself beyond the capacity")
+	assert.Equal(t, 2, a.MailboxMetrics().Depth)
+	assert.Equal(t, actor.ErrMailboxFull, s.SendMessage(actor.NewMessage(address, nil, "overflow")))
+}
@@ -1,6 +1,7 @@
 package actor
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -11,6 +12,9 @@ type Message struct {
 	WithResponse    bool
 	ResponseChan    chan WrappedMessageWithError
 	ResponseTimeout int
+	// Priority is used by priority mailboxes: higher values are delivered first
+	Priority int
+	ctx      context.Context
 }
 
 var EmptyMessage = Message{}
@@ -53,6 +57,24 @@ func (msg *Message) SetTimeout(value int) {
 	msg.ResponseTimeout = value
 }
 
+func (msg *Message) SetPriority(value int) {
+	msg.Priority = value
+}
+
+// Context returns the context of the message, the background context if not set
+func (msg Message) Context() context.Context {
+	if msg.ctx == nil {
+		return context.Background()
+	}
+	return msg.ctx
+}
+
+// WithContext returns a copy of the message carrying ctx: deadline, cancellation and values are propagated to the state processor
+func (msg Message) WithContext(ctx context.Context) Message {
+	msg.ctx = ctx
+	return msg
+}
+
 type WrappedMessageWithError struct {
 	Message *Message
 	Err     error
@@ -0,0 +1,321 @@
+package actor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+)
+
+var (
+	ErrOutboundCorrelationMismatch = errors.New("outbound response correlation id does not match the request")
+	ErrOutboundServiceInit         = errors.New("outbound message service fail on init")
+)
+
+// startOutboundService connects the transport, if needed, and subscribes to the messages addressed to the outbound area of the system
+func (s *ActorSystem) startOutboundService() error {
+	oo := s.outboundOptions
+
+	if oo.transport == nil {
+		if oo.nats == nil {
+			oo.nats = &natsConfig{}
+		}
+		nc, dialed, err := oo.nats.connect()
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrOutboundServiceInit, err)
+		}
+		oo.transport = NewNatsTransport(nc)
+		oo.ownsTransport = dialed
+	}
+
+	subj := fmt.Sprintf("%s.*.*", GetOutboundAreaPrefix(oo.outboundArea))
+	sub, err := oo.transport.Subscribe(subj, s.handleOutboundMessage)
+	if err != nil {
+		s.stopOutboundService(context.Background())
+		return fmt.Errorf("%w: %w", ErrOutboundServiceInit, err)
+	}
+	oo.subscription = sub
+	if n, ok := oo.transport.(TransportLinkNotifier); ok {
+		n.OnLinkLost(s.remoteLinkLost)
+	}
+	if n, ok := oo.transport.(TransportLinkRestoreNotifier); ok {
+		n.OnLinkRestored(s.remoteLinkRestored)
+	}
+	slog.Info("outbound service is active", slog.String("subject", subj))
+	s.events.Publish(TopicRemoteLinkUp, RemoteLinkUp{Area: oo.outboundArea})
+	return nil
+}
+
+// stopOutboundService unsubscribes the outbound area, flushes the transport and closes it if created by the system
+func (s *ActorSystem) stopOutboundService(ctx context.Context) error {
+	s.unsubscribeOutbound()
+
+	oo := s.outboundOptions
+	if oo.transport == nil {
+		return nil
+	}
+	var err error
+	if f, ok := oo.transport.(TransportFlusher); ok {
+		err = f.Flush(ctx)
+	}
+	if oo.ownsTransport {
+		oo.transport.Close()
+	}
+	return err
+}
+
+// unsubscribeOutbound stops receiving messages from the other apps
+func (s *ActorSystem) unsubscribeOutbound() {
+	oo := s.outboundOptions
+	if oo.subscription != nil {
+		oo.subscription.Unsubscribe()
+		oo.subscription = nil
+	}
+}
+
+// OutboundMessageHandler delivers a message received from a NATS subscription, see handleOutboundMessage
+func (s *ActorSystem) OutboundMessageHandler(msg *nats.Msg) {
+	s.handleOutboundMessage(natsToTransportMessage(msg))
+}
+
+// handleOutboundMessage delivers a message received from a remote app to the local actor addressed by the subject.
+// The body is decoded to the registered type and the sender is available as outbound address in From.
+// If the message has a reply subject, the response of the local actor is published to it.
+func (s *ActorSystem) handleOutboundMessage(msg TransportMessage) {
+	slog.Info("outbound message received", slog.String("msg", string(msg.Data)), slog.String("subj", string(msg.Subject)))
+	rawAddressSource := strings.Split(msg.Subject, AddressSeparator)
+	if len(rawAddressSource) < 4 {
+		slog.Error("outbound message subject is invalid", slog.Any("parts", rawAddressSource))
+		return
+	}
+
+	localActorAddress := NewAddress(
+		rawAddressSource[2],
+		rawAddressSource[3],
+	)
+
+	var envelop OutboundEvenlope
+
+	err := json.Unmarshal(msg.Data, &envelop)
+	if err != nil {
+		slog.Error("outbound message payload is invalid", slog.String("err", err.Error()))
+		return
+	}
+
+	if msg.Reply != "" {
+		envelop.ReplyTo = msg.Reply
+	}
+
+	payload, err := s.outboundOptions.typeRegistry.Decode(envelop.BodyType, envelop.RawBody)
+	if err != nil {
+		slog.Error("outbound message payload is invalid", slog.String("type", envelop.BodyType), slog.String("err", err.Error()))
+		s.replyOutboundError(envelop, err)
+		s.deadLetter(NewMessage(localActorAddress, envelop.From.Address(), envelop), err)
+		return
+	}
+
+	slog.Info("outbound message envelop", slog.Any("payload", payload))
+
+	if envelop.ReplyTo != "" {
+		go s.handleOutboundRequest(localActorAddress, envelop, payload)
+		return
+	}
+
+	finalMsg := NewMessage(
+		localActorAddress,
+		envelop.From.Address(),
+		payload,
+	)
+
+	err = s.SendMessage(finalMsg)
+	if err != nil {
+		slog.Error("outbound message fail to be send", slog.String("err", err.Error()))
+	}
+}
+
+// handleOutboundRequest asks the local actor and publishes its response to the reply subject of the request
+func (s *ActorSystem) handleOutboundRequest(localActorAddress *Address, request OutboundEvenlope, payload any) {
+	localMsg := NewMessageWithResponse(
+		localActorAddress,
+		request.From.Address(),
+		payload,
+	)
+	if request.Timeout > 0 {
+		localMsg.SetTimeout(request.Timeout)
+	}
+
+	returnMsg, err := s.Ask(localMsg)
+	if err != nil {
+		s.replyOutboundError(request, err)
+		return
+	}
+
+	var reply OutboundEvenlope
+	if returnMsg.Body != nil {
+		reply, err = NewOutboundEnvelope(returnMsg.Body, reflect.TypeOf(returnMsg.Body).String())
+		if err != nil {
+			s.replyOutboundError(request, err)
+			return
+		}
+	}
+	reply.CorrelationID = request.CorrelationID
+	s.publishOutboundReply(request.ReplyTo, reply)
+}
+
+func (s *ActorSystem) replyOutboundError(request OutboundEvenlope, err error) {
+	if request.ReplyTo == "" {
+		return
+	}
+	reply := OutboundEvenlope{
+		CorrelationID: request.CorrelationID,
+		Error:         err.Error(),
+	}
+	s.publishOutboundReply(request.ReplyTo, reply)
+}
+
+func (s *ActorSystem) publishOutboundReply(replyTo string, reply OutboundEvenlope) {
+	payload, err := json.Marshal(reply)
+	if err != nil {
+		slog.Error("outbound reply fail to be encoded", slog.String("err", err.Error()))
+		return
+	}
+
+	err = s.outboundOptions.transport.Publish(replyTo, payload)
+	if err != nil {
+		slog.Error("outbound error on publish reply", slog.String("err", err.Error()))
+	}
+}
+
+func (s *ActorSystem) isOutboundEnabled() bool {
+	return s.enableOutboundMessages && s.outboundOptions != nil && s.outboundOptions.transport != nil
+}
+
+func (s *ActorSystem) sendOutboundMessage(msg Message) error {
+	if !s.isOutboundEnabled() {
+		return ErrOutboundServiceNotEnabled
+	}
+
+	envelopPayload, err := s.encodeOutboundMessage(msg, nil)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("outbound message", slog.String("to", msg.To.String()))
+	err = s.outboundOptions.transport.Publish(msg.To.String(), envelopPayload)
+	if err != nil {
+		slog.Error("outbound error on publish", slog.String("err", err.Error()))
+	}
+	return err
+}
+
+// askOutboundMessage sends the message to a remote actor as a transport request with a correlation id, then waits for the reply
+// within the message timeout or until the message context is done
+func (s *ActorSystem) askOutboundMessage(msg Message) (Message, error) {
+	if !s.isOutboundEnabled() {
+		return EmptyMessage, ErrOutboundServiceNotEnabled
+	}
+
+	ctx := msg.Context()
+	if err := ctx.Err(); err != nil {
+		return EmptyMessage, err
+	}
+	timeout := time.Duration(msg.ResponseTimeout) * time.Second
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	correlationID := nuid.Next()
+	envelopPayload, err := s.encodeOutboundMessage(msg, func(envelop *OutboundEvenlope) {
+		envelop.CorrelationID = correlationID
+		envelop.Timeout = msg.ResponseTimeout
+	})
+	if err != nil {
+		return EmptyMessage, err
+	}
+
+	slog.Info("outbound message with response", slog.String("to", msg.To.String()), slog.String("correlation-id", correlationID))
+	replyData, err := s.requestOutbound(ctx, msg.To.String(), envelopPayload, timeout)
+	if err != nil {
+		if errors.Is(err, ErrTransportRequestTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return EmptyMessage, ErrSendWithReturnTimeout
+		}
+		slog.Error("outbound error on request", slog.String("err", err.Error()))
+		return EmptyMessage, err
+	}
+
+	var reply OutboundEvenlope
+	err = json.Unmarshal(replyData, &reply)
+	if err != nil {
+		return EmptyMessage, err
+	}
+
+	return s.decodeOutboundReply(msg, reply, correlationID)
+}
+
+// requestOutbound runs the transport request until the reply is received or ctx is done
+func (s *ActorSystem) requestOutbound(ctx context.Context, subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		replyData, err := s.outboundOptions.transport.Request(subject, data, timeout)
+		done <- result{replyData, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *ActorSystem) decodeOutboundReply(request Message, reply OutboundEvenlope, correlationID string) (Message, error) {
+	if reply.CorrelationID != correlationID {
+		return EmptyMessage, ErrOutboundCorrelationMismatch
+	}
+
+	if reply.Error != "" {
+		return EmptyMessage, errors.New(reply.Error)
+	}
+
+	var body any
+	if reply.BodyType != "" {
+		payload, err := s.outboundOptions.typeRegistry.Decode(reply.BodyType, reply.RawBody)
+		if err != nil {
+			slog.Error("outbound response payload is invalid", slog.String("type", reply.BodyType), slog.String("err", err.Error()))
+			return EmptyMessage, err
+		}
+		body = payload
+	}
+
+	return NewMessage(request.From, request.To, body), nil
+}
+
+func (s *ActorSystem) encodeOutboundMessage(msg Message, decorate func(*OutboundEvenlope)) ([]byte, error) {
+	if msg.Body == nil {
+		return nil, ErrOutboundMessageBodyMustBeNotNil
+	}
+
+	bodyType := reflect.TypeOf(msg.Body).String()
+	envelop, err := NewOutboundEnvelope(msg.Body, bodyType)
+	if err != nil {
+		return nil, err
+	}
+	envelop.From = NewOutboundEnvelopeAddress(s.outboundOptions.outboundArea, msg.From)
+
+	if decorate != nil {
+		decorate(&envelop)
+	}
+
+	return json.Marshal(envelop)
+}
@@ -0,0 +1,183 @@
+package actor_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type RemoteGreeting struct {
+	Text string `json:"text"`
+}
+
+type RemotePing struct {
+	Value int `json:"value"`
+}
+
+type RemotePong struct {
+	Value int `json:"value"`
+}
+
+type RemoteSlow struct{}
+
+var remoteRegistry = actor.EnvelopePayloadTypeRegistry{
+	"actor_test.RemoteGreeting": reflect.TypeOf(RemoteGreeting{}),
+	"actor_test.RemotePing":     reflect.TypeOf(RemotePing{}),
+	"actor_test.RemotePong":     reflect.TypeOf(RemotePong{}),
+	"actor_test.RemoteSlow":     reflect.TypeOf(RemoteSlow{}),
+}
+
+// remoteProcessor records the received messages, answers pings and greets back the sender of a greeting
+type remoteProcessor struct {
+	received chan actor.Message
+	system   *actor.ActorSystem
+	self     *actor.Address
+}
+
+func (p *remoteProcessor) Process(msg actor.Message) {
+	switch payload := msg.Body.(type) {
+	case RemotePing:
+		msg.ResponseChan <- actor.NewReturnMessage(RemotePong{Value: payload.Value + 1}, msg, nil)
+	case RemoteSlow:
+		<-time.After(1500 * time.Millisecond)
+	case RemoteGreeting:
+		if payload.Text == "hello" && msg.From != nil {
+			p.system.SendMessage(actor.NewMessage(msg.From, p.self, RemoteGreeting{Text: "hello back"}))
+		}
+	}
+	p.received <- msg
+}
+
+func (p *remoteProcessor) Shutdown() {}
+
+func (p *remoteProcessor) GetState() any {
+	return nil
+}
+
+func receiveMessage(t *testing.T, c <-chan actor.Message) actor.Message {
+	select {
+	case msg := <-c:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message not received in time")
+	}
+	return actor.EmptyMessage
+}
+
+// setupRemoteApps creates two systems connected by a loopback network, with an actor each
+func setupRemoteApps(t *testing.T) (app1 *actor.ActorSystem, app2 *actor.ActorSystem, p1 *remoteProcessor, p2 *remoteProcessor) {
+	network := actor.NewLoopbackNetwork()
+	app1, err := actor.NewActorSystem(actor.WithTransport("app1", network.NewTransport(), remoteRegistry))
+	assert.NoError(t, err)
+	app2, err = actor.NewActorSystem(actor.WithTransport("app2", network.NewTransport(), remoteRegistry))
+	assert.NoError(t, err)
+
+	address := actor.NewAddress("local", "actor")
+	p1 = &remoteProcessor{received: make(chan actor.Message, 10), system: app1, self: address}
+	p2 = &remoteProcessor{received: make(chan actor.Message, 10), system: app2, self: address}
+	_, err = app1.RegisterActor(address, p1)
+	assert.NoError(t, err)
+	_, err = app2.RegisterActor(address, p2)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		app1.Shutdown()
+		app2.Shutdown()
+	})
+	return
+}
+
+func TestOutboundMessageOverLoopback(t *testing.T) {
+	app1, _, p1, p2 := setupRemoteApps(t)
+
+	msg := actor.NewMessage(
+		actor.NewOutboundAddress("app2", "local", "actor"),
+		actor.NewAddress("local", "actor"),
+		RemoteGreeting{Text: "hello"},
+	)
+	assert.NoError(t, app1.SendMessage(msg))
+
+	received := receiveMessage(t, p2.received)
+	assert.Equal(t, RemoteGreeting{Text: "hello"}, received.Body, "remote actor must receive the decoded body")
+	assert.Equal(t, "cinecity.app1.local.actor", received.From.String(), "remote actor must receive the sender outbound address")
+
+	reply := receiveMessage(t, p1.received)
+	assert.Equal(t, RemoteGreeting{Text: "hello back"}, reply.Body, "sender must receive the reply of the remote actor")
+	assert.Equal(t, "cinecity.app2.local.actor", reply.From.String())
+}
+
+func TestOutboundAskOverLoopback(t *testing.T) {
+	app1, _, _, _ := setupRemoteApps(t)
+
+	msg := actor.NewMessageWithResponse(
+		actor.NewOutboundAddress("app2", "local", "actor"),
+		actor.NewAddress("local", "actor"),
+		RemotePing{Value: 41},
+	)
+	response, err := actor.AskAs[RemotePong](app1, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, RemotePong{Value: 42}, response)
+}
+
+func TestOutboundAskTimeoutOverLoopback(t *testing.T) {
+	app1, _, _, _ := setupRemoteApps(t)
+
+	msg := actor.NewMessageWithResponse(actor.NewOutboundAddress("app2", "local", "actor"), nil, RemoteSlow{})
+	msg.SetTimeout(1)
+	start := time.Now()
+	_, err := app1.Ask(msg)
+	assert.Equal(t, actor.ErrSendWithReturnTimeout, err)
+	assert.Less(t, time.Since(start), 1400*time.Millisecond, "caller timeout must be honored")
+}
+
+func TestOutboundAskRemoteErrors(t *testing.T) {
+	app1, _, _, _ := setupRemoteApps(t)
+
+	msg := actor.NewMessageWithResponse(actor.NewOutboundAddress("app2", "local", "missing"), nil, RemotePing{Value: 1})
+	_, err := app1.Ask(msg)
+	assert.EqualError(t, err, actor.ErrActorNotFound.Error(), "remote error must be returned to the caller")
+
+	msg = actor.NewMessageWithResponse(actor.NewOutboundAddress("app2", "local", "actor"), nil, fmt.Errorf("not registered"))
+	_, err = app1.Ask(msg)
+	assert.Error(t, err, "unknown body type must be returned as error")
+}
+
+func TestNatsOutboundServiceInitErrors(t *testing.T) {
+	_, err := actor.NewActorSystem(actor.WithNatsOutboundMessageService("app1", remoteRegistry))
+	assert.ErrorIs(t, err, actor.ErrOutboundServiceInit)
+	assert.ErrorIs(t, err, actor.ErrNatsServerNotConfigured)
+
+	_, err = actor.NewActorSystem(actor.WithNatsOutboundMessageService(
+		"app1",
+		remoteRegistry,
+		actor.WithNatsURLs("nats://127.0.0.1:1"),
+		actor.WithNatsToken("secret"),
+		actor.WithNatsReconnect(0, time.Millisecond),
+	))
+	assert.ErrorIs(t, err, actor.ErrOutboundServiceInit, "unreachable server must fail the init")
+
+	_, err = actor.NewActorSystem(actor.WithNatsOutboundMessageService(
+		"app1",
+		remoteRegistry,
+		actor.WithNatsURLs("nats://127.0.0.1:1"),
+		actor.WithNatsNkey("missing.nk"),
+	))
+	assert.ErrorIs(t, err, actor.ErrOutboundServiceInit, "unreadable nkey seed must fail the init")
+
+	_, err = actor.NewActorSystem(actor.WithOutboundMessageService("app1", nil, remoteRegistry))
+	assert.ErrorIs(t, err, actor.ErrNatsServerNotConfigured)
+}
+
+func TestTransportIsNotClosedOnShutdown(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	transport := network.NewTransport()
+	s, err := actor.NewActorSystem(actor.WithTransport("app1", transport, remoteRegistry))
+	assert.NoError(t, err)
+
+	s.Shutdown()
+	assert.NoError(t, transport.Publish("cinecity.app1.local.actor", []byte("{}")), "caller transport must be still usable")
+}
@@ -1,7 +1,40 @@
 package actor
 
+import "context"
+
 type StateProcessor interface {
 	Process(msg Message)
 	Shutdown()
 	GetState() any
 }
+
+// ContextStateProcessor is a StateProcessor that receives the context of the message: it is cancelled when the sender gives up or the system shuts down.
+// Actors call ProcessContext instead of Process when the state processor implements it.
+type ContextStateProcessor interface {
+	StateProcessor
+	ProcessContext(ctx context.Context, msg Message)
+}
+
+// PreStarter is implemented by state processors that open resources before processing messages.
+// PreStart is called at registration, before the actor is activated, and on the new state processor of a restart:
+// an error prevents the registration, or fails the restart.
+type PreStarter interface {
+	PreStart(ctx context.Context) error
+}
+
+// PostStopper is implemented by state processors that release resources after the actor stops, it is called after Shutdown
+type PostStopper interface {
+	PostStop()
+}
+
+// PreRestarter is implemented by state processors that need to know why they are replaced by a supervisor restart.
+// PreRestart is called on the failed state processor with the failure reason and the message being processed, before Shutdown.
+type PreRestarter interface {
+	PreRestart(reason any, msg Message)
+}
+
+// PostRestarter is implemented by state processors that need to know they replace a failed one.
+// PostRestart is called on the new state processor, after PreStart and before it processes messages.
+type PostRestarter interface {
+	PostRestart(reason any)
+}
@@ -0,0 +1,403 @@
+package actor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+type WaitForCancel struct{}
+
+// contextProcessor reports the contexts received by ProcessContext and waits for cancellation on WaitForCancel
+type contextProcessor struct {
+	contexts chan context.Context
+}
+
+func newContextProcessor() *contextProcessor {
+	return &contextProcessor{contexts: make(chan context.Context, 10)}
+}
+
+func (p *contextProcessor) Process(msg actor.Message) {
+	p.ProcessContext(msg.Context(), msg)
+}
+
+func (p *contextProcessor) ProcessContext(ctx context.Context, msg actor.Message) {
+	p.contexts <- ctx
+	switch msg.Body.(type) {
+	case WaitForCancel:
+		<-ctx.Done()
+		if msg.WithResponse {
+			msg.ResponseChan <- actor.NewReturnMessage(nil, msg, ctx.Err())
+		}
+	default:
+		if msg.WithResponse {
+			msg.ResponseChan <- actor.NewReturnMessage(msg.Body, msg, nil)
+		}
+	}
+}
+
+func (p *contextProcessor) Shutdown() {}
+
+func (p *contextProcessor) GetState() any {
+	return nil
+}
+
+// legacyProcessor implements only Process and reports the context of the messages
+type legacyProcessor struct {
+	contexts chan context.Context
+}
+
+func (p *legacyProcessor) Process(msg actor.Message) {
+	p.contexts <- msg.Context()
+}
+
+func (p *legacyProcessor) Shutdown() {}
+
+func (p *legacyProcessor) GetState() any {
+	return nil
+}
+
+func receiveContext(t *testing.T, c <-chan context.Context) context.Context {
+	select {
+	case ctx := <-c:
+		return ctx
+	case <-time.After(time.Second):
+		t.Fatal("message not processed in time")
+	}
+	return nil
+}
+
+func TestContextValuesArePropagated(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	cp := newContextProcessor()
+	lp := &legacyProcessor{contexts: make(chan context.Context, 10)}
+	cpAddress := actor.NewAddress("local", "context")
+	lpAddress := actor.NewAddress("local", "legacy")
+	_, err := s.RegisterActor(cpAddress, cp)
+	assert.NoError(t, err)
+	_, err = s.RegisterActor(lpAddress, lp)
+	assert.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	assert.NoError(t, s.SendMessageContext(ctx, actor.NewMessage(cpAddress, nil, "hello")))
+	assert.NoError(t, s.SendMessageContext(ctx, actor.NewMessage(lpAddress, nil, "hello")))
+
+	assert.Equal(t, "trace-1", receiveContext(t, cp.contexts).Value(traceKey{}))
+	assert.Equal(t, "trace-1", receiveContext(t, lp.contexts).Value(traceKey{}), "legacy processors read the context from the message")
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(lpAddress, nil, "hello")))
+	assert.NotNil(t, receiveContext(t, lp.contexts), "messages without context carry the background one")
+}
+
+func TestAskDeadlineIsPropagated(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	cp := newContextProcessor()
+	address := actor.NewAddress("local", "context")
+	_, err := s.RegisterActor(address, cp)
+	assert.NoError(t, err)
+
+	msg := actor.NewMessageWithResponse(address, nil, "hello")
+	msg.SetTimeout(2)
+	response, err := actor.AskAs[string](s, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", response)
+
+	deadline, ok := receiveContext(t, cp.contexts).Deadline()
+	assert.True(t, ok, "ask timeout must be the deadline of the processing context")
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+}
+
+func TestAskCancelledByCaller(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	cp := newContextProcessor()
+	address := actor.NewAddress("local", "context")
+	_, err := s.RegisterActor(address, cp)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err = s.AskContext(ctx, actor.NewMessageWithResponse(address, nil, WaitForCancel{}))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	processingCtx := receiveContext(t, cp.contexts)
+	select {
+	case <-processingCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("processing context must be cancelled when the caller gives up")
+	}
+
+	_, err = s.AskContext(ctx, actor.NewMessageWithResponse(address, nil, "too late"))
+	assert.ErrorIs(t, err, context.Canceled, "message of a cancelled context must not be processed")
+	assert.Empty(t, cp.contexts)
+}
+
+func TestAskDeadlineExceededReturnsTimeout(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	address := actor.NewAddress("local", "context")
+	_, err := s.RegisterActor(address, newContextProcessor())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = s.AskContext(ctx, actor.NewMessageWithResponse(address, nil, WaitForCancel{}))
+	assert.Equal(t, actor.ErrSendWithReturnTimeout, err)
+}
+
+func TestProcessingContextCancelledOnShutdown(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	cp := newContextProcessor()
+	address := actor.NewAddress("local", "context")
+	_, err := s.RegisterActor(address, cp)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, WaitForCancel{})))
+	processingCtx := receiveContext(t, cp.contexts)
+
+	go s.Shutdown()
+	select {
+	case <-processingCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("processing context must be cancelled on system shutdown")
+	}
+}
+
+// lifecycleLog records the lifecycle events of the processors created by a factory
+type lifecycleLog struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (l *lifecycleLog) add(event string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *lifecycleLog) get() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string{}, l.events...)
+}
+
+type lifecycleProcessor struct {
+	name     string
+	log      *lifecycleLog
+	startErr error
+}
+
+func (p *lifecycleProcessor) Process(msg actor.Message) {
+	if body, ok := msg.Body.(PanicBody); ok {
+		panic(string(body))
+	}
+}
+
+func (p *lifecycleProcessor) PreStart(ctx context.Context) error {
+	p.log.add(p.name + ":pre-start")
+	return p.startErr
+}
+
+func (p *lifecycleProcessor) PostStop() {
+	p.log.add(p.name + ":post-stop")
+}
+
+func (p *lifecycleProcessor) PreRestart(reason any, msg actor.Message) {
+	p.log.add(fmt.Sprintf("%s:pre-restart %v %v", p.name, reason, msg.Body))
+}
+
+func (p *lifecycleProcessor) PostRestart(reason any) {
+	p.log.add(fmt.Sprintf("%s:post-restart %v", p.name, reason))
+}
+
+func (p *lifecycleProcessor) Shutdown() {
+	p.log.add(p.name + ":shutdown")
+}
+
+func (p *lifecycleProcessor) GetState() any {
+	return nil
+}
+
+func TestLifecycleHooksOnRegisterAndDrop(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	a, err := s.RegisterActor(actor.NewAddress("local", "lifecycle"), &lifecycleProcessor{name: "p", log: log})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p:pre-start"}, log.get())
+
+	a.Drop()
+	a.Drop()
+	assert.Equal(t, []string{"p:pre-start", "p:shutdown", "p:post-stop"}, log.get(), "stop hooks are called once")
+}
+
+func TestPreStartErrorPreventsRegistration(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	startErr := errors.New("resource not available")
+	address := actor.NewAddress("local", "lifecycle")
+	_, err := s.RegisterActor(address, &lifecycleProcessor{name: "p", log: log, startErr: startErr})
+	assert.ErrorIs(t, err, actor.ErrActorStartFailed)
+	assert.ErrorIs(t, err, startErr)
+	assert.Equal(t, 0, s.NumActors())
+	assert.Equal(t, []string{"p:pre-start"}, log.get(), "a processor not started is not stopped")
+
+	_, err = s.RegisterActor(address, &lifecycleProcessor{name: "q", log: log})
+	assert.NoError(t, err, "address is free after a failed registration")
+}
+
+func TestLifecycleHooksOnRestart(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	instances := 0
+	spec := actor.ChildSpec{
+		Address: actor.NewAddress("supervised", "lifecycle"),
+		Factory: func() actor.StateProcessor {
+			instances++
+			return &lifecycleProcessor{name: fmt.Sprintf("p%d", instances), log: log}
+		},
+	}
+	_, err := s.RegisterActor(actor.NewAddress("test", "supervisor"), actor.NewSupervisor([]actor.ChildSpec{spec}))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom"))))
+	assert.Eventually(t, func() bool {
+		return len(log.get()) == 6
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{
+		"p1:pre-start",
+		"p1:pre-restart boom boom",
+		"p1:shutdown",
+		"p1:post-stop",
+		"p2:pre-start",
+		"p2:post-restart boom",
+	}, log.get())
+}
+
+type DropSelf struct{}
+
+// slowProcessor logs the start and the end of the messages, waiting for release, and drops its actor on DropSelf
+type slowProcessor struct {
+	lifecycleProcessor
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *slowProcessor) ProcessContext(ctx context.Context, msg actor.Message) {
+	p.log.add(p.name + ":process-start")
+	if _, ok := msg.Body.(DropSelf); ok {
+		a, _ := actor.ActorFromContext(ctx)
+		a.DropContext(ctx)
+	} else {
+		p.started <- struct{}{}
+		<-p.release
+	}
+	p.log.add(fmt.Sprintf("%s:process-end %v", p.name, ctx.Err()))
+}
+
+func TestDropWaitsForMessageInProgress(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	p := &slowProcessor{lifecycleProcessor: lifecycleProcessor{name: "p", log: log}, started: make(chan struct{}, 1), release: make(chan struct{})}
+	a, err := s.RegisterActor(actor.NewAddress("local", "slow"), p)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "work")))
+	<-p.started
+	dropped := make(chan struct{})
+	go func() {
+		a.Drop()
+		close(dropped)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-dropped:
+		t.Fatal("drop must wait for the message in progress")
+	default:
+	}
+	assert.Equal(t, []string{"p:pre-start", "p:process-start"}, log.get(), "state processor is not shut down while processing")
+
+	close(p.release)
+	select {
+	case <-dropped:
+	case <-time.After(time.Second):
+		t.Fatal("drop not completed after the message")
+	}
+	assert.Equal(t, []string{
+		"p:pre-start",
+		"p:process-start",
+		"p:process-end context canceled",
+		"p:shutdown",
+		"p:post-stop",
+	}, log.get(), "processing context is cancelled by drop")
+}
+
+func TestActorDropsItself(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	log := &lifecycleLog{}
+	p := &slowProcessor{lifecycleProcessor: lifecycleProcessor{name: "p", log: log}}
+	a, err := s.RegisterActor(actor.NewAddress("local", "slow"), p)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, DropSelf{})))
+	assert.Eventually(t, func() bool {
+		return len(log.get()) == 5
+	}, time.Second, 5*time.Millisecond, "an actor dropping itself does not wait for its own message")
+	events := log.get()
+	assert.Equal(t, []string{"p:pre-start", "p:process-start", "p:shutdown", "p:post-stop"}, events[:4])
+	assert.Contains(t, events[4], "p:process-end", "the message goes on after the drop")
+	assert.Equal(t, 0, s.NumActors())
+}
+
+func TestDropContextDoesNotWaitForBlockedProcessor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	p := &blockingProcessor{release: make(chan struct{})}
+	defer close(p.release)
+	a, err := s.RegisterActor(actor.NewAddress("local", "blocked"), p)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "work")))
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	dropped := make(chan struct{})
+	go func() {
+		a.DropContext(ctx)
+		close(dropped)
+	}()
+
+	select {
+	case <-dropped:
+	case <-time.After(time.Second):
+		t.Fatal("drop must not wait for a processor ignoring its context")
+	}
+	assert.Equal(t, 0, s.NumActors())
+}
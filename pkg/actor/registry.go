@@ -0,0 +1,93 @@
+package actor
+
+import (
+	"sort"
+	"sync"
+)
+
+// registry keeps the actors of a system indexed by address and it is safe for concurrent use
+type registry struct {
+	mutex  sync.RWMutex
+	actors map[string]*Actor
+	seq    uint64
+}
+
+func newRegistry() *registry {
+	return &registry{
+		actors: make(map[string]*Actor, 10),
+	}
+}
+
+// add stores the actor if its address is not already registered
+func (r *registry) add(a *Actor) error {
+	key := a.GetAddress().String()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.actors[key]; ok {
+		return ErrActorAddressAlreadyRegistered
+	}
+	r.seq++
+	a.seq = r.seq
+	r.actors[key] = a
+	return nil
+}
+
+// remove deletes the actor registered with the given address, if it is the expected one (nil matches any actor)
+func (r *registry) remove(address *Address, expected *Actor) {
+	key := address.String()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if current, ok := r.actors[key]; ok && (expected == nil || current == expected) {
+		delete(r.actors, key)
+	}
+}
+
+func (r *registry) get(address *Address) *Actor {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.actors[address.String()]
+}
+
+// snapshot returns the actors registered at the time of the call, so they can be iterated without holding the lock
+func (r *registry) snapshot() []*Actor {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	result := make([]*Actor, 0, len(r.actors))
+	for _, a := range r.actors {
+		result = append(result, a)
+	}
+	return result
+}
+
+// reverseOrder returns the registered actors from the last registered to the first one
+func (r *registry) reverseOrder() []*Actor {
+	result := r.snapshot()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].seq > result[j].seq
+	})
+	return result
+}
+
+// clear removes all actors and returns them
+func (r *registry) clear() []*Actor {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := make([]*Actor, 0, len(r.actors))
+	for _, a := range r.actors {
+		result = append(result, a)
+	}
+	r.actors = make(map[string]*Actor, 10)
+	return result
+}
+
+func (r *registry) len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.actors)
+}
@@ -0,0 +1,143 @@
+package actor_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProcessor struct {
+	counter *atomic.Int64
+}
+
+func (p *countingProcessor) Process(msg actor.Message) {
+	p.counter.Add(1)
+}
+
+func (p *countingProcessor) Shutdown() {}
+
+func (p *countingProcessor) GetState() any {
+	return p.counter.Load()
+}
+
+func TestRegistryConcurrentRegistration(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	counter := &atomic.Int64{}
+	var registered atomic.Int64
+	var wg sync.WaitGroup
+
+	// every address is contended by 4 goroutines: only one must win
+	for i := range 50 {
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				address := actor.NewAddress("hammer", fmt.Sprintf("actor-%d", i))
+				_, err := s.RegisterActor(address, &countingProcessor{counter})
+				if err == nil {
+					registered.Add(1)
+				} else {
+					assert.Equal(t, actor.ErrActorAddressAlreadyRegistered, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), registered.Load(), "each address must be registered once")
+	assert.Equal(t, 50, s.NumActors())
+}
+
+func TestRegistryConcurrentDelivery(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	counter := &atomic.Int64{}
+	stableAddress := actor.NewAddress("hammer", "stable")
+	_, err := s.RegisterActor(stableAddress, &countingProcessor{counter})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var sent atomic.Int64
+
+	// churn: actors registered and dropped while messages are sent and broadcasted
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 20 {
+				address := actor.NewAddress("churn", fmt.Sprintf("actor-%d-%d", i, j))
+				a, err := s.RegisterActor(address, &countingProcessor{&atomic.Int64{}})
+				if assert.NoError(t, err) {
+					s.SendMessage(actor.NewMessage(address, nil, "hello"))
+					a.Drop()
+				}
+			}
+		}()
+	}
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if s.SendMessage(actor.NewMessage(stableAddress, nil, "hello")) == nil {
+					sent.Add(1)
+				}
+			}
+		}()
+	}
+
+	area := "churn"
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				s.BroadcastMessage(actor.NewBroadcastMessage(stableAddress, "broadcast"), &area)
+				s.NumActors()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(500), sent.Load(), "all messages to the stable actor must be delivered")
+	assert.Eventually(t, func() bool {
+		return counter.Load() == 500
+	}, time.Second, 10*time.Millisecond, "stable actor must process all messages")
+	assert.Equal(t, 1, s.NumActors(), "only the stable actor must remain registered")
+}
+
+func TestRegistryUnregisterAndShutdownConcurrently(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		address := actor.NewAddress("hammer", fmt.Sprintf("actor-%d", i))
+		_, err := s.RegisterActor(address, &countingProcessor{&atomic.Int64{}})
+		assert.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.SendMessage(actor.NewMessage(address, nil, "hello"))
+			s.UnRegisterActor(address)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Shutdown()
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 0, s.NumActors())
+}
@@ -0,0 +1,149 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+)
+
+var (
+	ErrMessageNotHandled = errors.New("message body type is not handled")
+)
+
+// Router is a state processor that dispatches every message to the handler registered for its body type with Handle or HandleAsk.
+// Messages without a handler are reported as dead letters, unless WithUnhandled sets a different hook.
+type Router struct {
+	handlers          map[reflect.Type]routeHandler
+	interfaceHandlers []interfaceRoute
+	unhandled         func(ctx context.Context, msg Message)
+	state             func() any
+	shutdown          func()
+	actor             *Actor
+}
+
+type routeHandler func(ctx context.Context, msg Message)
+
+type interfaceRoute struct {
+	bodyType reflect.Type
+	handler  routeHandler
+}
+
+type RouterOption func(*Router)
+
+// WithUnhandled sets the hook called for messages without a handler
+func WithUnhandled(fn func(ctx context.Context, msg Message)) RouterOption {
+	return func(r *Router) {
+		r.unhandled = fn
+	}
+}
+
+// WithRouterState sets the function returning the state of the router actor
+func WithRouterState(fn func() any) RouterOption {
+	return func(r *Router) {
+		r.state = fn
+	}
+}
+
+// WithRouterShutdown sets the function called when the router actor is dropped
+func WithRouterShutdown(fn func()) RouterOption {
+	return func(r *Router) {
+		r.shutdown = fn
+	}
+}
+
+func NewRouter(opts ...RouterOption) *Router {
+	r := &Router{
+		handlers: make(map[reflect.Type]routeHandler),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Handle registers the handler of the messages with body of type T; T can be an interface, matched when no concrete type handler exists
+func Handle[T any](r *Router, fn func(ctx context.Context, body T, msg Message)) {
+	r.route(reflect.TypeFor[T](), func(ctx context.Context, msg Message) {
+		fn(ctx, msg.Body.(T), msg)
+	})
+}
+
+// HandleAsk registers the handler of the messages with body of type T that replies with the returned body, or error, to the sender waiting for response
+func HandleAsk[T any, R any](r *Router, fn func(ctx context.Context, body T) (R, error)) {
+	r.route(reflect.TypeFor[T](), func(ctx context.Context, msg Message) {
+		resp, err := fn(ctx, msg.Body.(T))
+		if msg.WithResponse && msg.ResponseChan != nil {
+			if err != nil {
+				msg.ResponseChan <- NewReturnMessage(nil, msg, err)
+				return
+			}
+			msg.ResponseChan <- NewReturnMessage(resp, msg, nil)
+		}
+	})
+}
+
+func (r *Router) route(bodyType reflect.Type, handler routeHandler) {
+	if bodyType.Kind() == reflect.Interface {
+		r.interfaceHandlers = append(r.interfaceHandlers, interfaceRoute{bodyType, handler})
+		return
+	}
+	r.handlers[bodyType] = handler
+}
+
+func (r *Router) handler(body any) routeHandler {
+	if body == nil {
+		return nil
+	}
+	bodyType := reflect.TypeOf(body)
+	if h, ok := r.handlers[bodyType]; ok {
+		return h
+	}
+	for _, route := range r.interfaceHandlers {
+		if bodyType.Implements(route.bodyType) {
+			return route.handler
+		}
+	}
+	return nil
+}
+
+func (r *Router) bindActor(a *Actor) error {
+	r.actor = a
+	return nil
+}
+
+func (r *Router) Process(msg Message) {
+	r.ProcessContext(msg.Context(), msg)
+}
+
+func (r *Router) ProcessContext(ctx context.Context, msg Message) {
+	h := r.handler(msg.Body)
+	if h != nil {
+		h(ctx, msg)
+		return
+	}
+
+	if r.unhandled != nil {
+		r.unhandled(ctx, msg)
+		return
+	}
+
+	if r.actor != nil {
+		r.actor.system.deadLetter(msg, ErrMessageNotHandled)
+		return
+	}
+	slog.Warn("message not handled", slog.String("msg", msg.String()))
+}
+
+func (r *Router) Shutdown() {
+	if r.shutdown != nil {
+		r.shutdown()
+	}
+}
+
+func (r *Router) GetState() any {
+	if r.state != nil {
+		return r.state()
+	}
+	return nil
+}
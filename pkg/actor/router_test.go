@@ -0,0 +1,139 @@
+package actor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type AddProduct struct {
+	Code string
+}
+
+type CountProducts struct{}
+
+type RemoveProduct struct {
+	Code string
+}
+
+type Unknown struct{}
+
+type Describable interface {
+	Describe() string
+}
+
+type DescribedProduct struct {
+	Code string
+}
+
+func (p DescribedProduct) Describe() string {
+	return "product " + p.Code
+}
+
+var errProductMissing = errors.New("product missing")
+
+// newProductsRouter creates a router keeping a set of product codes, its channel reports the descriptions handled
+func newProductsRouter(opts ...actor.RouterOption) (*actor.Router, chan string) {
+	products := make(map[string]bool)
+	described := make(chan string, 10)
+
+	opts = append(opts, actor.WithRouterState(func() any {
+		return len(products)
+	}))
+	r := actor.NewRouter(opts...)
+	actor.Handle(r, func(ctx context.Context, body AddProduct, msg actor.Message) {
+		products[body.Code] = true
+	})
+	actor.HandleAsk(r, func(ctx context.Context, body CountProducts) (int, error) {
+		return len(products), nil
+	})
+	actor.HandleAsk(r, func(ctx context.Context, body RemoveProduct) (bool, error) {
+		if !products[body.Code] {
+			return false, errProductMissing
+		}
+		delete(products, body.Code)
+		return true, nil
+	})
+	actor.Handle(r, func(ctx context.Context, body Describable, msg actor.Message) {
+		described <- body.Describe()
+	})
+	return r, described
+}
+
+func TestRouterDispatchesByBodyType(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	r, described := newProductsRouter()
+	a, err := s.RegisterActor(actor.NewAddress("local", "products"), r)
+	assert.NoError(t, err)
+
+	ref := actor.RefOf[AddProduct](a)
+	assert.NoError(t, ref.Send(context.Background(), nil, AddProduct{Code: "A"}))
+	assert.NoError(t, ref.Send(context.Background(), nil, AddProduct{Code: "B"}))
+
+	count, err := actor.Ask[CountProducts, int](context.Background(), actor.RefOf[CountProducts](a), nil, CountProducts{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	removeRef := actor.NewRef[RemoveProduct](s, a.GetAddress())
+	removed, err := actor.Ask[RemoveProduct, bool](context.Background(), removeRef, nil, RemoveProduct{Code: "A"})
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	_, err = actor.Ask[RemoveProduct, bool](context.Background(), removeRef, nil, RemoveProduct{Code: "A"})
+	assert.Equal(t, errProductMissing, err, "handler error must be returned to the caller")
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, DescribedProduct{Code: "C"})))
+	select {
+	case d := <-described:
+		assert.Equal(t, "product C", d, "interface handler must match implementing bodies")
+	case <-time.After(time.Second):
+		t.Fatal("interface handler not called")
+	}
+
+	assert.Equal(t, 1, a.GetState())
+}
+
+func TestTypedAskWrongResponseType(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	r, _ := newProductsRouter()
+	a, err := s.RegisterActor(actor.NewAddress("local", "products"), r)
+	assert.NoError(t, err)
+
+	_, err = actor.Ask[CountProducts, string](context.Background(), actor.RefOf[CountProducts](a), nil, CountProducts{})
+	assert.Equal(t, actor.ErrInboxReturnMessageBodyTypeWrong, err)
+}
+
+func TestRouterUnhandledMessages(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	r, _ := newProductsRouter()
+	a, err := s.RegisterActor(actor.NewAddress("local", "products"), r)
+	assert.NoError(t, err)
+
+	_, err = actor.Ask[Unknown, any](context.Background(), actor.RefOf[Unknown](a), nil, Unknown{})
+	assert.Equal(t, actor.ErrMessageNotHandled, err, "unhandled ask must be answered as dead letter")
+
+	unhandled := make(chan actor.Message, 1)
+	custom, _ := newProductsRouter(actor.WithUnhandled(func(ctx context.Context, msg actor.Message) {
+		unhandled <- msg
+	}))
+	b, err := s.RegisterActor(actor.NewAddress("local", "custom"), custom)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(b.GetAddress(), nil, "not handled")))
+	select {
+	case msg := <-unhandled:
+		assert.Equal(t, "not handled", msg.Body)
+	case <-time.After(time.Second):
+		t.Fatal("unhandled hook not called")
+	}
+}
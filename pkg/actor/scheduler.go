@@ -0,0 +1,229 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidInterval = errors.New("schedule interval must be positive")
+)
+
+// Scheduler sends messages after a delay, at a given time or periodically.
+// Schedules of the scheduler of an actor are cancelled when the actor is dropped, the ones of the system scheduler on shutdown.
+type Scheduler struct {
+	system *ActorSystem
+	timers *timerSet
+}
+
+// Schedule is the handle of a scheduled message
+type Schedule struct {
+	scheduler *Scheduler
+	msg       Message
+	// next returns the next activation after the previous one, or the zero time if the schedule is over
+	next      func(previous time.Time) time.Time
+	mutex     sync.Mutex
+	timer     ClockTimer
+	due       time.Time
+	cancelled bool
+}
+
+// timerSet keeps the active schedules of an owner
+type timerSet struct {
+	mutex     sync.Mutex
+	schedules map[*Schedule]struct{}
+	closed    bool
+}
+
+func newTimerSet() *timerSet {
+	return &timerSet{
+		schedules: make(map[*Schedule]struct{}),
+	}
+}
+
+func (ts *timerSet) add(sch *Schedule) bool {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.closed {
+		return false
+	}
+	ts.schedules[sch] = struct{}{}
+	return true
+}
+
+func (ts *timerSet) remove(sch *Schedule) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	delete(ts.schedules, sch)
+}
+
+func (ts *timerSet) len() int {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return len(ts.schedules)
+}
+
+// cancelAll cancels the active schedules; if close is true new schedules are rejected
+func (ts *timerSet) cancelAll(close bool) {
+	ts.mutex.Lock()
+	schedules := make([]*Schedule, 0, len(ts.schedules))
+	for sch := range ts.schedules {
+		schedules = append(schedules, sch)
+	}
+	ts.schedules = make(map[*Schedule]struct{})
+	ts.closed = ts.closed || close
+	ts.mutex.Unlock()
+
+	for _, sch := range schedules {
+		sch.Cancel()
+	}
+}
+
+// WithClock sets the clock of the schedulers of the system, RealClock by default
+func WithClock(clock Clock) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.clock = clock
+	}
+}
+
+// Clock returns the clock of the system
+func (s *ActorSystem) Clock() Clock {
+	return s.clock
+}
+
+// Scheduler returns the scheduler of the system, its schedules are cancelled on shutdown
+func (s *ActorSystem) Scheduler() *Scheduler {
+	return &Scheduler{system: s, timers: s.timers}
+}
+
+// Scheduler returns the scheduler of the actor, its schedules are cancelled when the actor is dropped
+func (a *Actor) Scheduler() *Scheduler {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.timers == nil {
+		a.timers = newTimerSet()
+		if a.isDropped {
+			a.timers.closed = true
+		}
+	}
+	return &Scheduler{system: a.system, timers: a.timers}
+}
+
+// After sends the message once after the delay
+func (sc *Scheduler) After(delay time.Duration, msg Message) *Schedule {
+	return sc.schedule(msg, sc.system.clock.Now().Add(delay), nil)
+}
+
+// At sends the message once at the given time, at once if it is in the past
+func (sc *Scheduler) At(t time.Time, msg Message) *Schedule {
+	return sc.schedule(msg, t, nil)
+}
+
+// Every sends the message every interval, the first time after one interval; it fails with ErrInvalidInterval if interval is not positive
+func (sc *Scheduler) Every(interval time.Duration, msg Message) (*Schedule, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	next := func(previous time.Time) time.Time {
+		return previous.Add(interval)
+	}
+	return sc.schedule(msg, next(sc.system.clock.Now()), next), nil
+}
+
+// Cron sends the message at every activation of the cron expression, see ParseCron
+func (sc *Scheduler) Cron(expr string, msg Message) (*Schedule, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	return sc.schedule(msg, cron.Next(sc.system.clock.Now()), cron.Next), nil
+}
+
+func (sc *Scheduler) schedule(msg Message, due time.Time, next func(time.Time) time.Time) *Schedule {
+	sch := &Schedule{
+		scheduler: sc,
+		msg:       msg,
+		next:      next,
+	}
+	if due.IsZero() || !sc.timers.add(sch) {
+		sch.cancelled = true
+		return sch
+	}
+
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+	sch.arm(due)
+	return sch
+}
+
+// arm starts the timer of the next activation; the caller must hold the lock
+func (sch *Schedule) arm(due time.Time) {
+	sch.due = due
+	clock := sch.scheduler.system.clock
+	sch.timer = clock.AfterFunc(due.Sub(clock.Now()), sch.fire)
+}
+
+func (sch *Schedule) fire() {
+	sch.mutex.Lock()
+	if sch.cancelled {
+		sch.mutex.Unlock()
+		return
+	}
+	due := sch.due
+	var next time.Time
+	if sch.next != nil {
+		next = sch.next(due)
+	}
+	if next.IsZero() {
+		sch.cancelled = true
+		sch.scheduler.timers.remove(sch)
+	} else {
+		sch.arm(next)
+	}
+	sch.mutex.Unlock()
+
+	sch.scheduler.system.sendInternal(sch.msg)
+}
+
+// Cancel stops the schedule, it returns false if it was not active
+func (sch *Schedule) Cancel() bool {
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+
+	if sch.cancelled {
+		return false
+	}
+	sch.cancelled = true
+	if sch.timer != nil {
+		sch.timer.Stop()
+	}
+	sch.scheduler.timers.remove(sch)
+	return true
+}
+
+// IsActive reports if the message will be sent again
+func (sch *Schedule) IsActive() bool {
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+	return !sch.cancelled
+}
+
+// Next returns the time of the next activation, the zero time if the schedule is not active
+func (sch *Schedule) Next() time.Time {
+	sch.mutex.Lock()
+	defer sch.mutex.Unlock()
+	if sch.cancelled {
+		return time.Time{}
+	}
+	return sch.due
+}
+
+type actorContextKey struct{}
+
+// ActorFromContext returns the actor processing the message whose context is ctx
+func ActorFromContext(ctx context.Context) (*Actor, bool) {
+	a, ok := ctx.Value(actorContextKey{}).(*Actor)
+	return a, ok
+}
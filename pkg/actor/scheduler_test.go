@@ -0,0 +1,196 @@
+package actor_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+var schedulerStart = time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC)
+
+// setupScheduled creates a system with a manual clock and an actor counting the messages received
+func setupScheduled(t *testing.T) (*actor.ActorSystem, *actor.ManualClock, *actor.Actor, *atomic.Int64) {
+	clock := actor.NewManualClock(schedulerStart)
+	s, err := actor.NewActorSystem(actor.WithClock(clock))
+	assert.NoError(t, err)
+
+	counter := &atomic.Int64{}
+	a, err := s.RegisterActor(actor.NewAddress("local", "scheduled"), &countingProcessor{counter})
+	assert.NoError(t, err)
+	return s, clock, a, counter
+}
+
+func assertReceived(t *testing.T, counter *atomic.Int64, expected int64) {
+	assert.Eventually(t, func() bool {
+		return counter.Load() == expected
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, expected, counter.Load())
+}
+
+func TestSchedulerAfterAndAt(t *testing.T) {
+	s, clock, a, counter := setupScheduled(t)
+	defer s.Shutdown()
+
+	msg := actor.NewMessage(a.GetAddress(), nil, "tick")
+	after := s.Scheduler().After(time.Minute, msg)
+	at := s.Scheduler().At(schedulerStart.Add(time.Hour), msg)
+	assert.Equal(t, schedulerStart.Add(time.Minute), after.Next())
+
+	clock.Advance(59 * time.Second)
+	assertReceived(t, counter, 0)
+
+	clock.Advance(time.Second)
+	assertReceived(t, counter, 1)
+	assert.False(t, after.IsActive(), "a delayed message is sent once")
+	assert.True(t, at.IsActive())
+
+	clock.Set(schedulerStart.Add(2 * time.Hour))
+	assertReceived(t, counter, 2)
+	assert.False(t, at.IsActive())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestSchedulerEveryAndCancel(t *testing.T) {
+	s, clock, a, counter := setupScheduled(t)
+	defer s.Shutdown()
+
+	every, err := s.Scheduler().Every(10*time.Second, actor.NewMessage(a.GetAddress(), nil, "tick"))
+	assert.NoError(t, err)
+	clock.Advance(35 * time.Second)
+	assertReceived(t, counter, 3)
+	assert.Equal(t, schedulerStart.Add(40*time.Second), every.Next(), "intervals are relative to the previous activation")
+
+	assert.True(t, every.Cancel())
+	assert.False(t, every.Cancel(), "a schedule is cancelled once")
+	assert.False(t, every.IsActive())
+	assert.True(t, every.Next().IsZero())
+
+	clock.Advance(time.Minute)
+	assertReceived(t, counter, 3)
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestSchedulerEveryRejectsNonPositiveInterval(t *testing.T) {
+	s, clock, a, counter := setupScheduled(t)
+	defer s.Shutdown()
+
+	msg := actor.NewMessage(a.GetAddress(), nil, "tick")
+	for _, interval := range []time.Duration{0, -time.Second} {
+		every, err := s.Scheduler().Every(interval, msg)
+		assert.ErrorIs(t, err, actor.ErrInvalidInterval)
+		assert.Nil(t, every)
+	}
+	assert.Equal(t, 0, clock.Pending())
+
+	clock.Advance(time.Minute)
+	assertReceived(t, counter, 0)
+}
+
+func TestSchedulerCron(t *testing.T) {
+	s, clock, a, counter := setupScheduled(t)
+	defer s.Shutdown()
+
+	_, err := s.Scheduler().Cron("every minute", actor.NewMessage(a.GetAddress(), nil, "tick"))
+	assert.ErrorIs(t, err, actor.ErrCronExpressionInvalid)
+
+	sch, err := s.Scheduler().Cron("*/15 8-9 * * *", actor.NewMessage(a.GetAddress(), nil, "tick"))
+	assert.NoError(t, err)
+	assert.Equal(t, schedulerStart.Add(15*time.Minute), sch.Next())
+
+	clock.Advance(2 * time.Hour)
+	assertReceived(t, counter, 7)
+	assert.Equal(t, schedulerStart.Add(24*time.Hour), sch.Next(), "next activation is on the next day")
+}
+
+func TestActorSchedulesCancelledOnDrop(t *testing.T) {
+	s, clock, a, counter := setupScheduled(t)
+	defer s.Shutdown()
+
+	target := actor.NewAddress("local", "target")
+	targetCounter := &atomic.Int64{}
+	_, err := s.RegisterActor(target, &countingProcessor{targetCounter})
+	assert.NoError(t, err)
+
+	owned, err := a.Scheduler().Every(time.Second, actor.NewMessage(target, a.GetAddress(), "tick"))
+	assert.NoError(t, err)
+	system, err := s.Scheduler().Every(time.Second, actor.NewMessage(target, nil, "tick"))
+	assert.NoError(t, err)
+	clock.Advance(time.Second)
+	assertReceived(t, targetCounter, 2)
+
+	a.Drop()
+	assert.False(t, owned.IsActive(), "schedules of the actor are cancelled when it is dropped")
+	assert.True(t, system.IsActive())
+	assert.False(t, a.Scheduler().After(time.Second, actor.NewMessage(target, nil, "tick")).IsActive(), "a dropped actor can't schedule")
+
+	clock.Advance(time.Second)
+	assertReceived(t, targetCounter, 3)
+	assert.Equal(t, int64(0), counter.Load())
+
+	s.Shutdown()
+	assert.False(t, system.IsActive(), "schedules of the system are cancelled on shutdown")
+	assert.Equal(t, 0, clock.Pending())
+}
+
+// selfScheduler schedules a reminder to itself through the actor found in the processing context
+type selfScheduler struct {
+	reminders chan string
+}
+
+func (p *selfScheduler) Process(msg actor.Message) {
+	p.ProcessContext(msg.Context(), msg)
+}
+
+func (p *selfScheduler) ProcessContext(ctx context.Context, msg actor.Message) {
+	switch body := msg.Body.(type) {
+	case time.Duration:
+		self, ok := actor.ActorFromContext(ctx)
+		if ok {
+			self.Scheduler().After(body, actor.NewMessage(self.GetAddress(), nil, "reminder"))
+		}
+		p.reminders <- "scheduled"
+	case string:
+		p.reminders <- body
+	}
+}
+
+func (p *selfScheduler) Shutdown() {}
+
+func (p *selfScheduler) GetState() any {
+	return nil
+}
+
+func TestActorSchedulesFromProcessingContext(t *testing.T) {
+	clock := actor.NewManualClock(schedulerStart)
+	s, err := actor.NewActorSystem(actor.WithClock(clock))
+	assert.NoError(t, err)
+	defer s.Shutdown()
+
+	p := &selfScheduler{reminders: make(chan string, 10)}
+	address := actor.NewAddress("local", "self")
+	_, err = s.RegisterActor(address, p)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, 5*time.Second)))
+	select {
+	case r := <-p.reminders:
+		assert.Equal(t, "scheduled", r)
+	case <-time.After(time.Second):
+		t.Fatal("message not processed in time")
+	}
+
+	clock.Advance(5 * time.Second)
+	select {
+	case r := <-p.reminders:
+		assert.Equal(t, "reminder", r)
+	case <-time.After(time.Second):
+		t.Fatal("reminder not received in time")
+	}
+	_, ok := actor.ActorFromContext(context.Background())
+	assert.False(t, ok)
+}
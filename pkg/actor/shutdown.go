@@ -0,0 +1,124 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// ShutdownHook is called on system shutdown after the actors are stopped, to flush and release shared resources
+type ShutdownHook func(ctx context.Context) error
+
+// ShutdownReport describes what a system shutdown has stopped and dropped
+type ShutdownReport struct {
+	// Stopped is the number of actors stopped
+	Stopped int
+	// Drained is the number of actors stopped after processing all the messages in their mailbox
+	Drained int
+	// Dropped is the number of pending messages discarded per actor address
+	Dropped map[string]int
+	// Errors are the errors returned by the shutdown hooks and by the transport flush
+	Errors []error
+}
+
+// DroppedMessages returns the total number of pending messages discarded
+func (r ShutdownReport) DroppedMessages() int {
+	total := 0
+	for _, n := range r.Dropped {
+		total += n
+	}
+	return total
+}
+
+// OnShutdown adds a hook called on shutdown after the actors are stopped; hooks are called in reverse order of registration
+func (s *ActorSystem) OnShutdown(hook ShutdownHook) {
+	s.hooksMutex.Lock()
+	defer s.hooksMutex.Unlock()
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// GracefulShutdown stops the system within the deadline of ctx:
+//   - the schedules of the system scheduler are cancelled
+//   - the messages from the other apps and from outside the actors are no more received: only the messages sent by the actors,
+//     see SendMessage, the scheduled ones and the ones of the runtime are delivered
+//   - actors are stopped from the last registered to the first one: every actor calls its drain hooks (see Actor.OnDrain), rejects
+//     new messages, processes the ones in its mailbox, saves the snapshot of its state if it is a Snapshotter and then it is dropped
+//   - shutdown hooks are called and the transport is flushed and closed
+//
+// When ctx is done the processing context of the messages is cancelled and the actors not yet stopped are dropped with their pending messages;
+// a message in progress that does not return within a short grace period is abandoned.
+// The returned error joins the context error, if the deadline was reached, and the errors of hooks and transport.
+func (s *ActorSystem) GracefulShutdown(ctx context.Context) (ShutdownReport, error) {
+	s.shutdownMutex.Lock()
+	defer s.shutdownMutex.Unlock()
+
+	report := ShutdownReport{
+		Dropped: make(map[string]int),
+	}
+	stopCancel := context.AfterFunc(ctx, s.cancelFunc)
+	defer stopCancel()
+
+	s.timers.cancelAll(true)
+	s.shuttingDown.Store(true)
+	defer s.shuttingDown.Store(false)
+
+	outbound := s.enableOutboundMessages && s.outboundOptions != nil
+	if outbound {
+		s.unsubscribeOutbound()
+	}
+
+	var deadlineErr error
+	for {
+		actors := s.registry.reverseOrder()
+		if len(actors) == 0 {
+			break
+		}
+		for _, a := range actors {
+			drained := a.drain(ctx)
+			if drained {
+				report.Drained++
+				a.snapshotOnShutdown(ctx)
+			} else {
+				deadlineErr = ctx.Err()
+			}
+			if pending := a.mailbox.Len() - int(a.internalMessages.Load()); pending > 0 {
+				report.Dropped[a.address.String()] += pending
+			}
+			if drained {
+				a.Drop()
+			} else {
+				a.dropWithin(stopGracePeriod)
+			}
+			report.Stopped++
+		}
+	}
+
+	s.hooksMutex.Lock()
+	hooks := s.shutdownHooks
+	s.shutdownHooks = nil
+	s.hooksMutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		err := hooks[i](ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+		}
+	}
+
+	if outbound {
+		err := s.stopOutboundService(ctx)
+		if err != nil {
+			report.Errors = append(report.Errors, err)
+		}
+	}
+
+	s.deadLetterActor.Drop()
+	s.cancelFunc()
+
+	slog.Info(
+		"actor system shut down",
+		slog.Int("stopped", report.Stopped),
+		slog.Int("drained", report.Drained),
+		slog.Int("dropped-messages", report.DroppedMessages()),
+	)
+	return report, errors.Join(append([]error{deadlineErr}, report.Errors...)...)
+}
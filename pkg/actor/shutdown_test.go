@@ -0,0 +1,243 @@
+package actor_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowForwarder processes every message slowly and forwards it to next, if set
+type slowForwarder struct {
+	system    *actor.ActorSystem
+	next      *actor.Address
+	processed *atomic.Int64
+}
+
+func (p *slowForwarder) Process(msg actor.Message) {
+	p.ProcessContext(context.Background(), msg)
+}
+
+func (p *slowForwarder) ProcessContext(ctx context.Context, msg actor.Message) {
+	time.Sleep(5 * time.Millisecond)
+	p.processed.Add(1)
+	if p.next != nil {
+		self, _ := actor.ActorFromContext(ctx)
+		p.system.SendMessage(actor.NewMessage(p.next, self.GetAddress(), msg.Body))
+	}
+}
+
+func (p *slowForwarder) Shutdown() {}
+
+func (p *slowForwarder) GetState() any {
+	return p.processed.Load()
+}
+
+func TestGracefulShutdownDrainsInReverseOrder(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	first := &slowForwarder{system: s, processed: &atomic.Int64{}}
+	firstAddress := actor.NewAddress("local", "first")
+	_, err := s.RegisterActor(firstAddress, first)
+	assert.NoError(t, err)
+
+	second := &slowForwarder{system: s, next: firstAddress, processed: &atomic.Int64{}}
+	secondAddress := actor.NewAddress("local", "second")
+	_, err = s.RegisterActor(secondAddress, second, actor.WithMailbox(actor.NewUnboundedMailbox()))
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(secondAddress, nil, i)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	report, err := s.GracefulShutdown(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Stopped)
+	assert.Equal(t, 2, report.Drained)
+	assert.Equal(t, 0, report.DroppedMessages())
+	assert.Equal(t, int64(10), second.processed.Load())
+	assert.Equal(t, int64(10), first.processed.Load(), "first registered actor must receive the messages of the actors stopped before it")
+
+	assert.True(t, s.IsShutdown())
+	assert.Equal(t, 0, s.NumActors())
+	assert.Equal(t, actor.ErrActorNotFound, s.SendMessage(actor.NewMessage(firstAddress, nil, "late")))
+}
+
+func TestGracefulShutdownDeadline(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	processor := &blockingProcessor{release: make(chan struct{})}
+	defer close(processor.release)
+	address := actor.NewAddress("local", "blocked")
+	_, err := s.RegisterActor(address, processor)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, i)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	report, err := s.GracefulShutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, report.Stopped)
+	assert.Equal(t, 0, report.Drained)
+	assert.Equal(t, 2, report.Dropped[address.String()], "messages not processed within the deadline are dropped")
+}
+
+func TestGracefulShutdownHooks(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	calls := make([]string, 0)
+	hookErr := errors.New("flush failed")
+	s.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "second")
+		return hookErr
+	})
+
+	report, err := s.GracefulShutdown(context.Background())
+	assert.ErrorIs(t, err, hookErr)
+	assert.Equal(t, []error{hookErr}, report.Errors)
+	assert.Equal(t, []string{"second", "first"}, calls, "hooks are called in reverse order")
+
+	_, err = s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, calls, 2, "hooks are called once")
+}
+
+func TestGracefulShutdownCallsDrainHooks(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	p := &slowForwarder{system: s, processed: &atomic.Int64{}}
+	address := actor.NewAddress("local", "buffered")
+	a, err := s.RegisterActor(address, p)
+	assert.NoError(t, err)
+	processedBeforeHook := int64(-1)
+	a.OnDrain(func() {
+		processedBeforeHook = p.processed.Load()
+		assert.NoError(t, s.SendMessage(actor.NewMessage(address, address, "flushed")), "the hook posts to its actor")
+	})
+
+	dropped, err := s.RegisterActor(actor.NewAddress("local", "dropped"), &slowForwarder{system: s, processed: &atomic.Int64{}})
+	assert.NoError(t, err)
+	dropped.OnDrain(func() {
+		t.Error("drain hook of a dropped actor called")
+	})
+	dropped.Drop()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, i)))
+	}
+	report, err := s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), processedBeforeHook, "hooks are called after the messages already in the mailbox")
+	assert.Equal(t, int64(6), p.processed.Load(), "the message posted by the hook is processed")
+	assert.Equal(t, 0, report.DroppedMessages())
+}
+
+func TestGracefulShutdownSkipsSuspendedActor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	spec, starts := fragileChild("one")
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec}, actor.WithBackoff(func(attempt int) time.Duration { return time.Minute })),
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom"))))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, "pending")))
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	report, err := s.GracefulShutdown(ctx)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second, "a suspended actor is not waited for")
+	assert.Equal(t, 2, report.Stopped)
+	assert.Equal(t, 1, report.Dropped[spec.Address.String()])
+	assert.Equal(t, int64(1), starts.Load())
+}
+
+func TestGracefulShutdownRejectsExternalMessages(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	first := &slowForwarder{system: s, processed: &atomic.Int64{}}
+	firstAddress := actor.NewAddress("local", "first")
+	_, err := s.RegisterActor(firstAddress, first)
+	assert.NoError(t, err)
+
+	blocked := &blockingProcessor{release: make(chan struct{})}
+	blockedAddress := actor.NewAddress("local", "blocked")
+	_, err = s.RegisterActor(blockedAddress, blocked)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(blockedAddress, nil, "work")))
+
+	done := make(chan struct{})
+	go func() {
+		s.GracefulShutdown(context.Background())
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.ErrorIs(t, s.SendMessage(actor.NewMessage(firstAddress, nil, "late")), actor.ErrInboxClosed, "actors not yet stopped reject external messages")
+	close(blocked.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown not completed")
+	}
+	assert.Equal(t, int64(0), first.processed.Load())
+}
+
+func TestGracefulShutdownAcceptsMessagesOfActors(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	target := &slowForwarder{system: s, processed: &atomic.Int64{}}
+	targetAddress := actor.NewAddress("local", "target")
+	_, err := s.RegisterActor(targetAddress, target)
+	assert.NoError(t, err)
+
+	blocked := &blockingProcessor{release: make(chan struct{})}
+	blockedAddress := actor.NewAddress("local", "blocked")
+	a, err := s.RegisterActor(blockedAddress, blocked)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(blockedAddress, nil, "work")))
+	a.Scheduler().After(50*time.Millisecond, actor.NewMessage(targetAddress, nil, "scheduled"))
+
+	done := make(chan struct{})
+	go func() {
+		s.GracefulShutdown(context.Background())
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// a goroutine of the blocked actor, sending as the actor
+	sent := make(chan error)
+	go func() {
+		sent <- s.SendMessage(actor.NewMessage(targetAddress, blockedAddress, "from goroutine"))
+	}()
+	assert.NoError(t, <-sent)
+	assert.ErrorIs(t, s.SendMessage(actor.NewMessage(targetAddress, nil, "external")), actor.ErrInboxClosed)
+	time.Sleep(50 * time.Millisecond)
+
+	close(blocked.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown not completed")
+	}
+	assert.Equal(t, int64(2), target.processed.Load(), "the messages of the goroutine and of the scheduler of the actor are delivered")
+}
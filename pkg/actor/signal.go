@@ -0,0 +1,111 @@
+package actor
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the time given to the graceful shutdown started by a signal
+const DefaultShutdownTimeout = 10 * time.Second
+
+// SignalHook is called when a shutdown signal is received, before the actors are stopped; ctx has the deadline of the shutdown
+// and sig is nil if the shutdown has been started by the cancellation of the context given to RunUntilSignal
+type SignalHook func(ctx context.Context, sig os.Signal)
+
+type signalConfig struct {
+	signals []os.Signal
+	timeout time.Duration
+	hooks   []SignalHook
+}
+
+type SignalOption func(*signalConfig)
+
+// WithSignals sets the signals that start the shutdown, SIGINT and SIGTERM by default
+func WithSignals(signals ...os.Signal) SignalOption {
+	return func(c *signalConfig) {
+		c.signals = signals
+	}
+}
+
+// WithShutdownTimeout sets the deadline of the graceful shutdown started by a signal
+func WithShutdownTimeout(timeout time.Duration) SignalOption {
+	return func(c *signalConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithSignalHook adds a hook to the chain called in order when a signal is received, e.g. to stop an HTTP server before the actors
+func WithSignalHook(hook SignalHook) SignalOption {
+	return func(c *signalConfig) {
+		c.hooks = append(c.hooks, hook)
+	}
+}
+
+func newSignalConfig(opts []SignalOption) signalConfig {
+	c := signalConfig{
+		signals: []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		timeout: DefaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithSignalShutdown makes the system listen to the configured signals and stop gracefully when one is received.
+// Signals are no more handled after the system shuts down.
+func WithSignalShutdown(opts ...SignalOption) ActorSystemOption {
+	return func(s *ActorSystem) {
+		config := newSignalConfig(opts)
+		// the signals are caught from now, before the system is returned
+		signals := notifySignals(config)
+		go func() {
+			defer signal.Stop(signals)
+			_, err := s.waitSignal(context.Background(), signals, config)
+			if err != nil {
+				slog.Warn("actor system shut down with errors", slog.String("err", err.Error()))
+			}
+		}()
+	}
+}
+
+// RunUntilSignal blocks until one of the configured signals is received or ctx is done, then calls the signal hooks in order
+// and stops the system gracefully within the shutdown timeout. If the system is shut down in the meantime it returns at once.
+func (s *ActorSystem) RunUntilSignal(ctx context.Context, opts ...SignalOption) (ShutdownReport, error) {
+	config := newSignalConfig(opts)
+	signals := notifySignals(config)
+	defer signal.Stop(signals)
+	return s.waitSignal(ctx, signals, config)
+}
+
+func notifySignals(config signalConfig) chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, config.signals...)
+	return signals
+}
+
+func (s *ActorSystem) waitSignal(ctx context.Context, signals <-chan os.Signal, config signalConfig) (ShutdownReport, error) {
+	var sig os.Signal
+	select {
+	case sig = <-signals:
+		slog.Info("shutdown signal received", slog.String("signal", sig.String()))
+	case <-ctx.Done():
+	case <-s.context.Done():
+	}
+	// the system may have been shut down while ctx was done or a signal was received
+	if s.context.Err() != nil {
+		return ShutdownReport{}, nil
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.timeout)
+	defer cancel()
+
+	for _, hook := range config.hooks {
+		hook(shutdownCtx, sig)
+	}
+	return s.GracefulShutdown(shutdownCtx)
+}
@@ -0,0 +1,102 @@
+package actor_test
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunUntilSignalStopsOnContextDone(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	_, err := s.RegisterActor(actor.NewAddress("local", "actor"), newContextProcessor())
+	assert.NoError(t, err)
+
+	calls := make([]string, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	report, err := s.RunUntilSignal(
+		ctx,
+		actor.WithSignals(syscall.SIGUSR2),
+		actor.WithShutdownTimeout(time.Second),
+		actor.WithSignalHook(func(ctx context.Context, sig os.Signal) {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline, "hooks receive the shutdown deadline")
+			assert.Nil(t, sig)
+			calls = append(calls, "http server")
+		}),
+		actor.WithSignalHook(func(ctx context.Context, sig os.Signal) {
+			calls = append(calls, "metrics")
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http server", "metrics"}, calls, "hooks are called in order")
+	assert.Equal(t, 1, report.Stopped)
+	assert.True(t, s.IsShutdown())
+}
+
+func TestWithSignalShutdown(t *testing.T) {
+	// keep SIGUSR1 from terminating the test process if delivered before the system listens to it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+
+	received := make(chan os.Signal, 1)
+	s, err := actor.NewActorSystem(actor.WithSignalShutdown(
+		actor.WithSignals(syscall.SIGUSR1),
+		actor.WithSignalHook(func(ctx context.Context, sig os.Signal) {
+			received <- sig
+		}),
+	))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		return s.IsShutdown()
+	}, time.Second, 20*time.Millisecond)
+	assert.Equal(t, syscall.SIGUSR1, <-received)
+}
+
+func TestWithSignalShutdownStopsListeningOnShutdown(t *testing.T) {
+	s, err := actor.NewActorSystem(actor.WithSignalShutdown(actor.WithSignals(syscall.SIGUSR2)))
+	assert.NoError(t, err)
+
+	s.Shutdown()
+	report, err := s.RunUntilSignal(context.Background(), actor.WithSignals(syscall.SIGUSR2))
+	assert.NoError(t, err, "a system already shut down returns at once")
+	assert.Equal(t, 0, report.Stopped)
+}
+
+// flushCounter counts the flushes of the transport, one per graceful shutdown
+type flushCounter struct {
+	actor.Transport
+	flushes atomic.Int64
+}
+
+func (t *flushCounter) Flush(ctx context.Context) error {
+	t.flushes.Add(1)
+	return nil
+}
+
+func TestWithSignalShutdownDoesNotShutDownTwice(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	for range 10 {
+		transport := &flushCounter{Transport: network.NewTransport()}
+		s, err := actor.NewActorSystem(
+			actor.WithTransport("app1", transport, remoteRegistry),
+			actor.WithSignalShutdown(actor.WithSignals(syscall.SIGUSR2)),
+		)
+		assert.NoError(t, err)
+
+		s.Shutdown()
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, int64(1), transport.flushes.Load(), "a programmatic shutdown is not repeated by the signal listener")
+	}
+}
@@ -0,0 +1,199 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSnapshotNotFound           = errors.New("snapshot not found")
+	ErrSnapshotNotSupported       = errors.New("state processor does not implement Snapshotter")
+	ErrSnapshotStoreNotConfigured = errors.New("snapshot store is not configured")
+)
+
+// Snapshotter is implemented by state processors whose state can be serialized: with a snapshot store configured on the system,
+// the state is restored when an actor is registered at the same address and saved on demand, periodically and on graceful shutdown
+type Snapshotter interface {
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// SnapshotStore keeps the latest snapshot of every actor, by address
+type SnapshotStore interface {
+	Save(ctx context.Context, key string, data []byte) error
+	// Load returns ErrSnapshotNotFound if there is no snapshot for the key
+	Load(ctx context.Context, key string) ([]byte, error)
+}
+
+// snapshotRequest is processed by the actor goroutine, between two messages, to snapshot the state without races
+type snapshotRequest struct{}
+
+// WithSnapshotStore sets the store of the snapshots of the state processors implementing Snapshotter
+func WithSnapshotStore(store SnapshotStore) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.snapshotStore = store
+	}
+}
+
+// WithSnapshotInterval makes the actor save a snapshot of its state every interval, with the scheduler of the actor
+func WithSnapshotInterval(interval time.Duration) ActorOption {
+	return func(a *Actor) {
+		a.snapshotInterval = interval
+	}
+}
+
+// SaveSnapshot saves the state of the actor after the messages already in its mailbox are processed, waiting until ctx is done
+func (a *Actor) SaveSnapshot(ctx context.Context) error {
+	msg := NewMessageWithResponse(a.address, nil, snapshotRequest{})
+	_, err := a.InboxAndWaitResponse(msg.WithContext(ctx))
+	return err
+}
+
+// restoreSnapshot restores the state of the processor from the latest snapshot, if any
+func (a *Actor) restoreSnapshot(ctx context.Context, p StateProcessor) error {
+	sn, ok := p.(Snapshotter)
+	store := a.system.snapshotStore
+	if !ok || store == nil {
+		return nil
+	}
+
+	data, err := store.Load(ctx, a.address.String())
+	if errors.Is(err, ErrSnapshotNotFound) {
+		return nil
+	}
+	if err == nil {
+		err = sn.Restore(data)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: snapshot restore: %w", ErrActorStartFailed, err)
+	}
+	slog.Info("actor state restored from snapshot", slog.String("address", a.address.String()))
+	return nil
+}
+
+// saveSnapshot stores the state of the processor; it must not run concurrently with the processing of a message
+func (a *Actor) saveSnapshot(ctx context.Context, p StateProcessor) error {
+	sn, ok := p.(Snapshotter)
+	if !ok {
+		return ErrSnapshotNotSupported
+	}
+	store := a.system.snapshotStore
+	if store == nil {
+		return ErrSnapshotStoreNotConfigured
+	}
+
+	data, err := sn.Snapshot()
+	if err != nil {
+		return err
+	}
+	return store.Save(ctx, a.address.String(), data)
+}
+
+// handleSnapshotRequest saves the snapshot on the actor goroutine and replies the result, if requested
+func (a *Actor) handleSnapshotRequest(p StateProcessor, msg Message) {
+	err := a.saveSnapshot(msg.Context(), p)
+	if err != nil {
+		slog.Warn("actor snapshot failed", slog.String("address", a.address.String()), slog.String("err", err.Error()))
+	}
+	if msg.WithResponse && msg.ResponseChan != nil {
+		msg.ResponseChan <- NewReturnMessage(nil, msg, err)
+	}
+}
+
+// snapshotOnShutdown saves the state of a drained actor, that is not processing messages anymore
+func (a *Actor) snapshotOnShutdown(ctx context.Context) {
+	a.mutex.RLock()
+	p := a.stateProcessor
+	suspended := a.suspended
+	a.mutex.RUnlock()
+
+	if _, ok := p.(Snapshotter); !ok || suspended || a.system.snapshotStore == nil {
+		return
+	}
+	err := a.saveSnapshot(ctx, p)
+	if err != nil {
+		slog.Warn("actor snapshot on shutdown failed", slog.String("address", a.address.String()), slog.String("err", err.Error()))
+	}
+}
+
+// MemorySnapshotStore keeps the snapshots in memory: they survive the actors, not the process
+type MemorySnapshotStore struct {
+	mutex     sync.RWMutex
+	snapshots map[string][]byte
+}
+
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{
+		snapshots: make(map[string][]byte),
+	}
+}
+
+func (s *MemorySnapshotStore) Save(ctx context.Context, key string, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.snapshots[key] = append([]byte{}, data...)
+	return nil
+}
+
+func (s *MemorySnapshotStore) Load(ctx context.Context, key string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	data, ok := s.snapshots[key]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	return append([]byte{}, data...), nil
+}
+
+// FileSnapshotStore keeps every snapshot in a file of a directory, replaced atomically on save
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore creates a store in dir, creating the directory if needed
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+func (s *FileSnapshotStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".snapshot")
+}
+
+func (s *FileSnapshotStore) Save(ctx context.Context, key string, data []byte) error {
+	path := s.path(key)
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileSnapshotStore) Load(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,141 @@
+package actor_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// tallyProcessor counts the messages per body and saves the counters as snapshot
+type tallyProcessor struct {
+	Counters map[string]int
+	restored bool
+}
+
+func newTallyProcessor() *tallyProcessor {
+	return &tallyProcessor{Counters: make(map[string]int)}
+}
+
+func (p *tallyProcessor) Process(msg actor.Message) {
+	if body, ok := msg.Body.(string); ok {
+		p.Counters[body]++
+	}
+}
+
+func (p *tallyProcessor) Snapshot() ([]byte, error) {
+	return json.Marshal(p.Counters)
+}
+
+func (p *tallyProcessor) Restore(data []byte) error {
+	p.restored = true
+	return json.Unmarshal(data, &p.Counters)
+}
+
+func (p *tallyProcessor) Shutdown() {}
+
+// GetState returns a copy of the counters, that the caller can read while the actor keeps processing
+func (p *tallyProcessor) GetState() any {
+	counters := make(map[string]int, len(p.Counters))
+	for key, n := range p.Counters {
+		counters[key] = n
+	}
+	return counters
+}
+
+var tallyAddress = actor.NewAddress("local", "tally")
+
+func TestSnapshotOnDemandAndRestore(t *testing.T) {
+	store := actor.NewMemorySnapshotStore()
+	s, err := actor.NewActorSystem(actor.WithSnapshotStore(store))
+	assert.NoError(t, err)
+	defer s.Shutdown()
+
+	first := newTallyProcessor()
+	a, err := s.RegisterActor(tallyAddress, first)
+	assert.NoError(t, err)
+	assert.False(t, first.restored, "there is no snapshot to restore")
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "a")))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "a")))
+	assert.NoError(t, a.SaveSnapshot(context.Background()), "snapshot follows the messages already in the mailbox")
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "b")))
+	a.Drop()
+
+	second := newTallyProcessor()
+	_, err = s.RegisterActor(tallyAddress, second)
+	assert.NoError(t, err)
+	assert.True(t, second.restored)
+	assert.Equal(t, map[string]int{"a": 2}, second.Counters)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	a, err := s.RegisterActor(tallyAddress, newTallyProcessor())
+	assert.NoError(t, err)
+	assert.Equal(t, actor.ErrSnapshotStoreNotConfigured, a.SaveSnapshot(context.Background()))
+
+	store := actor.NewMemorySnapshotStore()
+	withStore, _ := actor.NewActorSystem(actor.WithSnapshotStore(store))
+	defer withStore.Shutdown()
+	counting, err := withStore.RegisterActor(actor.NewAddress("local", "counting"), &countingProcessor{&atomic.Int64{}})
+	assert.NoError(t, err)
+	assert.Equal(t, actor.ErrSnapshotNotSupported, counting.SaveSnapshot(context.Background()))
+
+	assert.NoError(t, store.Save(context.Background(), tallyAddress.String(), []byte("not json")))
+	_, err = withStore.RegisterActor(tallyAddress, newTallyProcessor())
+	assert.ErrorIs(t, err, actor.ErrActorStartFailed, "a snapshot that can't be restored prevents the registration")
+}
+
+func TestSnapshotOnGracefulShutdown(t *testing.T) {
+	store, err := actor.NewFileSnapshotStore(t.TempDir())
+	assert.NoError(t, err)
+
+	s, _ := actor.NewActorSystem(actor.WithSnapshotStore(store))
+	_, err = s.RegisterActor(tallyAddress, newTallyProcessor())
+	assert.NoError(t, err)
+	for range 3 {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "c")))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = s.GracefulShutdown(ctx)
+	assert.NoError(t, err)
+
+	restarted, _ := actor.NewActorSystem(actor.WithSnapshotStore(store))
+	defer restarted.Shutdown()
+	p := newTallyProcessor()
+	_, err = restarted.RegisterActor(tallyAddress, p)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"c": 3}, p.Counters, "state resumes in a new system")
+
+	_, err = store.Load(context.Background(), "local.missing")
+	assert.True(t, errors.Is(err, actor.ErrSnapshotNotFound))
+}
+
+func TestPeriodicSnapshot(t *testing.T) {
+	clock := actor.NewManualClock(schedulerStart)
+	store := actor.NewMemorySnapshotStore()
+	s, _ := actor.NewActorSystem(actor.WithSnapshotStore(store), actor.WithClock(clock))
+	defer s.Shutdown()
+
+	_, err := s.RegisterActor(tallyAddress, newTallyProcessor(), actor.WithSnapshotInterval(time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "d")))
+
+	_, err = store.Load(context.Background(), tallyAddress.String())
+	assert.Equal(t, actor.ErrSnapshotNotFound, err)
+
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		data, err := store.Load(context.Background(), tallyAddress.String())
+		return err == nil && string(data) == `{"d":1}`
+	}, time.Second, 5*time.Millisecond)
+}
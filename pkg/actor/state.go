@@ -0,0 +1,109 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	ErrStateTypeWrong = errors.New("state type is wrong")
+	// errRunStopped reports that the goroutine processing the mailbox stopped before answering a state query
+	errRunStopped = errors.New("actor stopped processing")
+)
+
+// DefaultStateTimeout is the time GetState waits for the actor to read its state
+const DefaultStateTimeout = 5 * time.Second
+
+// stateQuery is processed by the actor goroutine, between two messages, to read the state without races
+type stateQuery struct{}
+
+// GetState returns the state of the state processor, read by the actor goroutine between two messages so it does not race with them:
+// the state processor should return a copy of maps, slices and pointers it keeps changing. It returns nil if the actor is dropped or the state is not read within DefaultStateTimeout, see GetStateContext.
+func (a *Actor) GetState() any {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultStateTimeout)
+	defer cancel()
+
+	state, err := a.GetStateContext(ctx)
+	if err != nil {
+		slog.Warn("actor state not available", slog.String("address", a.address.String()), slog.String("err", err.Error()))
+		return nil
+	}
+	return state
+}
+
+// GetStateContext returns the state of the state processor after the messages already in the mailbox are processed, waiting until ctx is done.
+// The state is read at once when ctx is the processing context of the actor itself or when the actor is suspended, waiting for a restart.
+// It fails with ErrActorDropped for a dropped actor and ErrSendWithReturnTimeout if the deadline of ctx is reached.
+func (a *Actor) GetStateContext(ctx context.Context) (any, error) {
+	for {
+		a.mutex.RLock()
+		p := a.stateProcessor
+		dropped := a.isDropped
+		suspended := a.suspended
+		run := a.run
+		a.mutex.RUnlock()
+
+		if dropped {
+			return nil, ErrActorDropped
+		}
+		if p == nil {
+			return nil, nil
+		}
+		if self, ok := ActorFromContext(ctx); suspended || (ok && self == a) {
+			return p.GetState(), nil
+		}
+
+		returnMsg, err := a.queryState(ctx, run)
+		if errors.Is(err, errRunStopped) {
+			// the actor has been suspended, restarted or dropped before reading the query
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, ErrInboxClosed) {
+				return nil, ErrActorDropped
+			}
+			return nil, err
+		}
+		return returnMsg.Body, nil
+	}
+}
+
+// queryState posts a stateQuery and waits for the response, until ctx is done or run, if not nil, stops processing the mailbox
+func (a *Actor) queryState(ctx context.Context, run *actorRun) (Message, error) {
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if run != nil {
+		go func() {
+			select {
+			case <-run.done:
+				cancel()
+			case <-queryCtx.Done():
+			}
+		}()
+	}
+
+	// the query skips the check of the closed inbox, so a draining actor can still be read
+	msg := NewMessageWithResponse(a.address, nil, stateQuery{}).WithContext(queryCtx)
+	returnMsg, err := a.postAndWait(msg, a.postInternal)
+	if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+		return EmptyMessage, errRunStopped
+	}
+	return returnMsg, err
+}
+
+// GetStateAs reads the state of the actor within timeout, see GetStateContext, and checks that it is of type T
+func GetStateAs[T any](a *Actor, timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state, err := a.GetStateContext(ctx)
+	if err != nil {
+		return *new(T), err
+	}
+	if typed, ok := state.(T); ok {
+		return typed, nil
+	}
+	return *new(T), ErrStateTypeWrong
+}
@@ -0,0 +1,195 @@
+package actor_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type ReadOwnState struct{}
+
+// selfReader reads its own state through the actor while processing ReadOwnState
+type selfReader struct {
+	tallyProcessor
+	states chan any
+}
+
+func (p *selfReader) ProcessContext(ctx context.Context, msg actor.Message) {
+	if _, ok := msg.Body.(ReadOwnState); ok {
+		self, _ := actor.ActorFromContext(ctx)
+		state, err := self.GetStateContext(ctx)
+		if err != nil {
+			p.states <- err
+			return
+		}
+		p.states <- state
+		return
+	}
+	p.Process(msg)
+}
+
+func TestGetStateIsSerializedWithMessages(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	a, err := s.RegisterActor(tallyAddress, newTallyProcessor())
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, fmt.Sprintf("key-%d", i%10))))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			counters, err := actor.GetStateAs[map[string]int](a, time.Second)
+			assert.NoError(t, err)
+			total := 0
+			for _, n := range counters {
+				total += n
+			}
+			assert.LessOrEqual(t, total, 100)
+		}
+	}()
+	wg.Wait()
+
+	counters, err := actor.GetStateAs[map[string]int](a, time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, counters, 10, "state is read after the messages already in the mailbox")
+	assert.Equal(t, counters, a.GetState())
+
+	_, err = actor.GetStateAs[string](a, time.Second)
+	assert.Equal(t, actor.ErrStateTypeWrong, err)
+
+	a.Drop()
+	_, err = a.GetStateContext(context.Background())
+	assert.Equal(t, actor.ErrActorDropped, err)
+	assert.Nil(t, a.GetState())
+}
+
+func TestGetStateTimeout(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	processor := &blockingProcessor{release: make(chan struct{})}
+	defer close(processor.release)
+	a, err := s.RegisterActor(actor.NewAddress("test", "busy"), processor)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "block")))
+
+	_, err = actor.GetStateAs[any](a, 20*time.Millisecond)
+	assert.Equal(t, actor.ErrSendWithReturnTimeout, err)
+}
+
+func TestGetStateFromOwnProcessing(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	p := &selfReader{tallyProcessor: *newTallyProcessor(), states: make(chan any, 1)}
+	_, err := s.RegisterActor(tallyAddress, p)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, "e")))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(tallyAddress, nil, ReadOwnState{})))
+
+	select {
+	case state := <-p.states:
+		assert.Equal(t, map[string]int{"e": 1}, state, "an actor reads its own state without waiting for itself")
+	case <-time.After(time.Second):
+		t.Fatal("state not read in time")
+	}
+}
+
+func TestGetStateOfSuspendedActor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	spec, _ := fragileChild("one")
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec}, actor.WithBackoff(func(attempt int) time.Duration { return time.Minute })),
+	)
+	assert.NoError(t, err)
+	child := s.Subtree(spec.Address)[0]
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, "first")))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom"))))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, "pending")))
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	count, err := actor.GetStateAs[int](child, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "the state of the crashed processor is read")
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "a suspended actor is not waited for")
+
+	report, err := s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Dropped[spec.Address.String()], "only the pending message is dropped")
+}
+
+// panicOnRelease panics processing a message when release is closed
+type panicOnRelease struct {
+	release chan struct{}
+}
+
+func (p *panicOnRelease) Process(msg actor.Message) {
+	<-p.release
+	panic("released")
+}
+
+func (p *panicOnRelease) Shutdown() {}
+
+func (p *panicOnRelease) GetState() any {
+	return "crashed"
+}
+
+func TestGetStateWhileActorFails(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	processor := &panicOnRelease{release: make(chan struct{})}
+	spec := actor.ChildSpec{
+		Address: actor.NewAddress("supervised", "one"),
+		Factory: func() actor.StateProcessor {
+			return processor
+		},
+	}
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec}, actor.WithBackoff(func(attempt int) time.Duration { return time.Minute })),
+	)
+	assert.NoError(t, err)
+	child := s.Subtree(spec.Address)[0]
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, "work")))
+	time.Sleep(20 * time.Millisecond)
+
+	states := make(chan any, 1)
+	go func() {
+		state, err := actor.GetStateAs[string](child, 2*time.Second)
+		if err != nil {
+			states <- err
+			return
+		}
+		states <- state
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(processor.release)
+
+	select {
+	case state := <-states:
+		assert.Equal(t, "crashed", state, "the query waiting in the mailbox is answered when the actor is suspended")
+	case <-time.After(time.Second):
+		t.Fatal("state not read in time")
+	}
+
+	report, err := s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, report.Dropped, "the unanswered query is not a dropped message")
+}
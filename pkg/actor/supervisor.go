@@ -0,0 +1,309 @@
+package actor
+
+import (
+	"log/slog"
+	"time"
+)
+
+// SupervisorStrategy defines which children are restarted when one of them fails
+type SupervisorStrategy int
+
+const (
+	// OneForOne restarts only the failed child
+	OneForOne SupervisorStrategy = iota
+	// OneForAll restarts all the children
+	OneForAll
+	// RestForOne restarts the failed child and the children started after it
+	RestForOne
+)
+
+func (strategy SupervisorStrategy) String() string {
+	switch strategy {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case RestForOne:
+		return "rest-for-one"
+	}
+	return "unknown"
+}
+
+// ChildSpec describes a supervised child: its address, the factory that creates a fresh state processor at every (re)start and the options of the actor
+type ChildSpec struct {
+	Address *Address
+	Factory func() StateProcessor
+	Options []ActorOption
+}
+
+// ChildFailed is sent by a supervised actor to its supervisor when its state processor panics
+type ChildFailed struct {
+	Child   *Address
+	Reason  any
+	Message Message
+}
+
+// Escalation is sent by a supervisor to its parent when the restart intensity is exceeded
+type Escalation struct {
+	Supervisor *Address
+	Child      *Address
+	Reason     any
+}
+
+// BackoffFunc returns the delay before the restart number attempt (starting from 0)
+type BackoffFunc func(attempt int) time.Duration
+
+// ExponentialBackoff doubles the delay at every attempt starting from minDelay up to maxDelay
+func ExponentialBackoff(minDelay, maxDelay time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := minDelay
+		for range attempt {
+			d *= 2
+			if d >= maxDelay {
+				return maxDelay
+			}
+		}
+		return d
+	}
+}
+
+type restartChildren struct {
+	children []*Address
+	reason   any
+	message  Message
+}
+
+// actorBinder is implemented by state processors that need to know the actor running them
+type actorBinder interface {
+	bindActor(a *Actor) error
+}
+
+type supervisedChild struct {
+	spec    ChildSpec
+	actor   *Actor
+	pending bool
+}
+
+// Supervisor is a state processor that owns child actors and restarts them when their state processor panics.
+// If the children fail more than the restart intensity allows, the supervisor stops them and escalates the failure to its parent.
+type Supervisor struct {
+	address     *Address
+	system      *ActorSystem
+	parent      *Address
+	strategy    SupervisorStrategy
+	maxRestarts int
+	window      time.Duration
+	backoff     BackoffFunc
+	specs       []ChildSpec
+	children    []*supervisedChild
+	restarts    []time.Time
+	escalated   bool
+}
+
+type SupervisorOption func(*Supervisor)
+
+func WithStrategy(strategy SupervisorStrategy) SupervisorOption {
+	return func(s *Supervisor) {
+		s.strategy = strategy
+	}
+}
+
+// WithRestartIntensity allows at most maxRestarts within the window before escalating; the window is measured by the clock of the system
+func WithRestartIntensity(maxRestarts int, window time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxRestarts = maxRestarts
+		s.window = window
+	}
+}
+
+// WithBackoff delays the restarts by the duration returned for the attempt, measured by the clock of the system
+func WithBackoff(backoff BackoffFunc) SupervisorOption {
+	return func(s *Supervisor) {
+		s.backoff = backoff
+	}
+}
+
+// WithParent sets the address receiving the escalations; by default it is the supervisor of the supervisor actor, if any
+func WithParent(parent *Address) SupervisorOption {
+	return func(s *Supervisor) {
+		s.parent = parent
+	}
+}
+
+// NewSupervisor creates a supervisor state processor; children are started when it is registered
+func NewSupervisor(children []ChildSpec, opts ...SupervisorOption) *Supervisor {
+	s := Supervisor{
+		strategy:    OneForOne,
+		maxRestarts: 3,
+		window:      5 * time.Second,
+		specs:       children,
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return &s
+}
+
+func (s *Supervisor) bindActor(a *Actor) error {
+	s.address = a.address
+	s.system = a.system
+	if s.parent == nil {
+		s.parent = a.supervisor
+	}
+
+	s.children = make([]*supervisedChild, 0, len(s.specs))
+	for _, spec := range s.specs {
+		opts := append([]ActorOption{withSupervisor(s.address)}, spec.Options...)
+		child, err := s.system.RegisterActor(spec.Address, spec.Factory(), opts...)
+		if err != nil {
+			s.Shutdown()
+			return err
+		}
+		s.children = append(s.children, &supervisedChild{spec: spec, actor: child})
+	}
+	return nil
+}
+
+func (s *Supervisor) Process(msg Message) {
+	switch payload := msg.Body.(type) {
+	case ChildFailed:
+		s.handleFailure(payload.Child, payload.Reason, payload.Message)
+	case Escalation:
+		s.handleFailure(payload.Supervisor, payload.Reason, EmptyMessage)
+	case restartChildren:
+		s.restart(payload.children, payload.reason, payload.message)
+	}
+}
+
+func (s *Supervisor) handleFailure(failed *Address, reason any, msg Message) {
+	if s.escalated {
+		return
+	}
+
+	idx := s.childIndex(failed)
+	if idx < 0 {
+		slog.Warn("failure of unknown child", slog.String("supervisor", s.address.String()), slog.String("child", failed.String()))
+		return
+	}
+
+	now := s.system.clock.Now()
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.window {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = recent
+
+	if len(s.restarts) >= s.maxRestarts {
+		s.escalate(failed, reason)
+		return
+	}
+	attempt := len(s.restarts)
+	s.restarts = append(s.restarts, now)
+
+	var toRestart []*supervisedChild
+	switch s.strategy {
+	case OneForAll:
+		toRestart = s.children
+	case RestForOne:
+		toRestart = s.children[idx:]
+	default:
+		toRestart = s.children[idx : idx+1]
+	}
+
+	addresses := make([]*Address, 0, len(toRestart))
+	for _, c := range toRestart {
+		c.pending = true
+		addresses = append(addresses, c.spec.Address)
+	}
+
+	slog.Info(
+		"supervisor restarting children",
+		slog.String("supervisor", s.address.String()),
+		slog.String("strategy", s.strategy.String()),
+		slog.Int("children", len(addresses)),
+		slog.Any("reason", reason),
+	)
+
+	var delay time.Duration
+	if s.backoff != nil {
+		delay = s.backoff(attempt)
+	}
+	if delay <= 0 {
+		s.restart(addresses, reason, msg)
+		return
+	}
+
+	self := s.address
+	system := s.system
+	system.clock.AfterFunc(delay, func() {
+		err := system.SendMessage(NewMessage(self, self, restartChildren{addresses, reason, msg}))
+		if err != nil {
+			slog.Warn("supervisor not reachable after backoff", slog.String("supervisor", self.String()), slog.String("err", err.Error()))
+		}
+	})
+}
+
+func (s *Supervisor) restart(addresses []*Address, reason any, msg Message) {
+	for _, addr := range addresses {
+		idx := s.childIndex(addr)
+		if idx < 0 || !s.children[idx].pending {
+			continue
+		}
+		c := s.children[idx]
+		c.pending = false
+		err := c.actor.restart(c.spec.Factory(), reason, msg)
+		if err != nil {
+			slog.Error("child restart failed", slog.String("child", addr.String()), slog.String("err", err.Error()))
+		}
+	}
+}
+
+func (s *Supervisor) escalate(failed *Address, reason any) {
+	s.escalated = true
+	slog.Error(
+		"supervisor restart intensity exceeded",
+		slog.String("supervisor", s.address.String()),
+		slog.String("child", failed.String()),
+		slog.Any("reason", reason),
+	)
+	s.Shutdown()
+
+	if s.parent == nil {
+		return
+	}
+
+	err := s.system.SendMessage(NewMessage(s.parent, s.address, Escalation{Supervisor: s.address, Child: failed, Reason: reason}))
+	if err != nil {
+		slog.Error("supervisor parent not reachable", slog.String("parent", s.parent.String()), slog.String("err", err.Error()))
+	}
+}
+
+func (s *Supervisor) childIndex(address *Address) int {
+	for i, c := range s.children {
+		if c.spec.Address.IsEqual(address) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Shutdown drops the children in reverse start order
+func (s *Supervisor) Shutdown() {
+	for i := len(s.children) - 1; i >= 0; i-- {
+		s.children[i].actor.Drop()
+	}
+	s.children = nil
+}
+
+// GetState returns the addresses of the running children
+func (s *Supervisor) GetState() any {
+	result := make([]*Address, 0, len(s.children))
+	for _, c := range s.children {
+		result = append(result, c.spec.Address)
+	}
+	return result
+}
@@ -0,0 +1,290 @@
+package actor_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type PanicBody string
+type AskCountBody struct{}
+
+type fragileProcessor struct {
+	processed *atomic.Int64
+	count     int
+}
+
+func (p *fragileProcessor) Process(msg actor.Message) {
+	switch msg.Body.(type) {
+	case PanicBody:
+		panic(string(msg.Body.(PanicBody)))
+	case AskCountBody:
+		msg.ResponseChan <- actor.NewReturnMessage(p.count, msg, nil)
+	default:
+		p.count++
+		p.processed.Add(1)
+	}
+}
+
+func (p *fragileProcessor) Shutdown() {}
+
+func (p *fragileProcessor) GetState() any {
+	return p.count
+}
+
+// fragileChild returns a child spec and the counter of processors created by its factory
+func fragileChild(id string) (actor.ChildSpec, *atomic.Int64) {
+	starts := &atomic.Int64{}
+	processed := &atomic.Int64{}
+	return actor.ChildSpec{
+		Address: actor.NewAddress("supervised", id),
+		Factory: func() actor.StateProcessor {
+			starts.Add(1)
+			return &fragileProcessor{processed: processed}
+		},
+	}, starts
+}
+
+type escalationRecorder struct {
+	escalations *atomic.Int64
+}
+
+func (r *escalationRecorder) Process(msg actor.Message) {
+	if _, ok := msg.Body.(actor.Escalation); ok {
+		r.escalations.Add(1)
+	}
+}
+
+func (r *escalationRecorder) Shutdown() {}
+
+func (r *escalationRecorder) GetState() any {
+	return r.escalations.Load()
+}
+
+func TestUnsupervisedActorSurvivesPanic(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	processed := &atomic.Int64{}
+	address := actor.NewAddress("test", "fragile")
+	_, err := s.RegisterActor(address, &fragileProcessor{processed: processed})
+	assert.NoError(t, err)
+
+	msg := actor.NewMessageWithResponse(address, nil, PanicBody("boom"))
+	_, err = s.Ask(msg)
+	assert.ErrorIs(t, err, actor.ErrProcessorPanic, "asker must receive the panic as error")
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(address, nil, "after panic")))
+	assert.Eventually(t, func() bool {
+		return processed.Load() == 1
+	}, 100*time.Millisecond, 10*time.Millisecond, "actor must keep processing after a panic")
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec1, starts1 := fragileChild("one")
+	spec2, starts2 := fragileChild("two")
+	_, err := s.RegisterActor(actor.NewAddress("test", "supervisor"), actor.NewSupervisor([]actor.ChildSpec{spec1, spec2}))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.NumActors(), "supervisor and its children must be registered")
+
+	s.SendMessage(actor.NewMessage(spec1.Address, nil, "inc"))
+	s.SendMessage(actor.NewMessage(spec1.Address, nil, PanicBody("boom")))
+	s.SendMessage(actor.NewMessage(spec1.Address, nil, "inc"))
+
+	assert.Eventually(t, func() bool {
+		return starts1.Load() == 2
+	}, time.Second, 10*time.Millisecond, "failed child must be recreated by the factory")
+	assert.Equal(t, int64(1), starts2.Load(), "sibling must not be restarted")
+
+	count, err := actor.AskAs[int](s, actor.NewMessageWithResponse(spec1.Address, nil, AskCountBody{}))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "restarted child has a fresh state and keeps the pending messages")
+}
+
+func TestSupervisorOneForAll(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec1, starts1 := fragileChild("one")
+	spec2, starts2 := fragileChild("two")
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec1, spec2}, actor.WithStrategy(actor.OneForAll)),
+	)
+	assert.NoError(t, err)
+
+	s.SendMessage(actor.NewMessage(spec2.Address, nil, PanicBody("boom")))
+
+	assert.Eventually(t, func() bool {
+		return starts1.Load() == 2 && starts2.Load() == 2
+	}, time.Second, 10*time.Millisecond, "all children must be restarted")
+}
+
+func TestSupervisorRestForOne(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec1, starts1 := fragileChild("one")
+	spec2, starts2 := fragileChild("two")
+	spec3, starts3 := fragileChild("three")
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec1, spec2, spec3}, actor.WithStrategy(actor.RestForOne)),
+	)
+	assert.NoError(t, err)
+
+	s.SendMessage(actor.NewMessage(spec2.Address, nil, PanicBody("boom")))
+
+	assert.Eventually(t, func() bool {
+		return starts2.Load() == 2 && starts3.Load() == 2
+	}, time.Second, 10*time.Millisecond, "failed child and the next ones must be restarted")
+	assert.Equal(t, int64(1), starts1.Load(), "children started before the failed one must not be restarted")
+}
+
+func TestSupervisorEscalatesWhenIntensityExceeded(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	parentAddress := actor.NewAddress("test", "parent")
+	escalations := &atomic.Int64{}
+	_, err := s.RegisterActor(parentAddress, &escalationRecorder{escalations})
+	assert.NoError(t, err)
+
+	spec, starts := fragileChild("one")
+	_, err = s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor(
+			[]actor.ChildSpec{spec},
+			actor.WithRestartIntensity(2, time.Minute),
+			actor.WithParent(parentAddress),
+		),
+	)
+	assert.NoError(t, err)
+
+	for range 3 {
+		s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom")))
+	}
+
+	assert.Eventually(t, func() bool {
+		return escalations.Load() == 1
+	}, time.Second, 10*time.Millisecond, "parent must receive the escalation")
+	assert.Equal(t, int64(3), starts.Load(), "child is restarted up to the intensity limit")
+	assert.Eventually(t, func() bool {
+		return s.NumActors() == 2
+	}, time.Second, 10*time.Millisecond, "children must be stopped after escalation")
+}
+
+func TestNestedSupervisorRestartedByParent(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec, starts := fragileChild("leaf")
+	childSupervisor := actor.ChildSpec{
+		Address: actor.NewAddress("test", "child-supervisor"),
+		Factory: func() actor.StateProcessor {
+			return actor.NewSupervisor([]actor.ChildSpec{spec}, actor.WithRestartIntensity(0, time.Minute))
+		},
+	}
+	_, err := s.RegisterActor(actor.NewAddress("test", "root"), actor.NewSupervisor([]actor.ChildSpec{childSupervisor}))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, s.NumActors())
+
+	s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom")))
+
+	assert.Eventually(t, func() bool {
+		return starts.Load() == 2 && s.NumActors() == 3
+	}, time.Second, 10*time.Millisecond, "escalated child supervisor must be restarted with its children")
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec, starts := fragileChild("one")
+	_, err := s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor([]actor.ChildSpec{spec}, actor.WithBackoff(actor.ExponentialBackoff(200*time.Millisecond, time.Second))),
+	)
+	assert.NoError(t, err)
+
+	s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom")))
+
+	<-time.After(100 * time.Millisecond)
+	assert.Equal(t, int64(1), starts.Load(), "restart must wait for the backoff")
+	assert.Eventually(t, func() bool {
+		return starts.Load() == 2
+	}, time.Second, 10*time.Millisecond, "child must be restarted after the backoff")
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := actor.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, backoff(0))
+	assert.Equal(t, 20*time.Millisecond, backoff(1))
+	assert.Equal(t, 40*time.Millisecond, backoff(2))
+	assert.Equal(t, 50*time.Millisecond, backoff(3))
+}
+
+func TestSupervisorChildAddressConflict(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	spec, _ := fragileChild("one")
+	_, err := s.RegisterActor(spec.Address, &fragileProcessor{processed: &atomic.Int64{}})
+	assert.NoError(t, err)
+
+	_, err = s.RegisterActor(actor.NewAddress("test", "supervisor"), actor.NewSupervisor([]actor.ChildSpec{spec}))
+	assert.True(t, errors.Is(err, actor.ErrActorAddressAlreadyRegistered))
+	assert.Equal(t, 1, s.NumActors(), "supervisor must not be registered if a child fails to start")
+}
+
+func TestSupervisorUsesSystemClock(t *testing.T) {
+	clock := actor.NewManualClock(time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC))
+	s, _ := actor.NewActorSystem(actor.WithClock(clock))
+	defer s.Shutdown()
+
+	parentAddress := actor.NewAddress("test", "parent")
+	escalations := &atomic.Int64{}
+	_, err := s.RegisterActor(parentAddress, &escalationRecorder{escalations})
+	assert.NoError(t, err)
+
+	spec, starts := fragileChild("one")
+	_, err = s.RegisterActor(
+		actor.NewAddress("test", "supervisor"),
+		actor.NewSupervisor(
+			[]actor.ChildSpec{spec},
+			actor.WithRestartIntensity(1, time.Minute),
+			actor.WithBackoff(func(attempt int) time.Duration { return time.Second }),
+			actor.WithParent(parentAddress),
+		),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom"))))
+	assert.Eventually(t, func() bool {
+		return clock.Pending() == 1
+	}, time.Second, 5*time.Millisecond, "backoff timer runs on the system clock")
+	assert.Equal(t, int64(1), starts.Load())
+
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return starts.Load() == 2
+	}, time.Second, 5*time.Millisecond, "child is restarted when the clock reaches the backoff")
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("boom"))))
+	assert.Eventually(t, func() bool {
+		return clock.Pending() == 1
+	}, time.Second, 5*time.Millisecond, "restarts out of the window are not counted")
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return starts.Load() == 3
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(0), escalations.Load())
+}
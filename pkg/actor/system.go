@@ -0,0 +1,407 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	ErrAddressInvalid                  = errors.New("address is invalid: area or id are empty")
+	ErrActorNotFound                   = errors.New("actor not found")
+	ErrActorAddressAlreadyRegistered   = errors.New("actor address already registered")
+	ErrInboxReturnMessageBodyTypeWrong = errors.New("return body message type is wrong")
+	ErrOutboundMessageBodyMustBeNotNil = errors.New("outbound message body must be not nil")
+	ErrOutboundServiceNotEnabled       = errors.New("outbound message service is not enabled")
+)
+
+// ActorSystem is an isolated group of actors: it owns the actors registry, the context shared by the actors and the optional outbound message service.
+// More systems can live in the same process without sharing anything.
+type ActorSystem struct {
+	registry               *registry
+	context                context.Context
+	cancelFunc             func()
+	enableOutboundMessages bool
+	outboundOptions        *OutboundOptions
+	mailboxFactory         MailboxFactory
+	shutdownMutex          sync.Mutex
+	hooksMutex             sync.Mutex
+	shutdownHooks          []ShutdownHook
+	deadLetterCapacity     int
+	deadLetterOffice       *DeadLetterOffice
+	deadLetterActor        *Actor
+	clock                  Clock
+	timers                 *timerSet
+	watchMutex             sync.Mutex
+	remoteWatchers         map[string]*remoteWatch
+	snapshotStore          SnapshotStore
+	events                 *EventStream
+	// shuttingDown is set while a graceful shutdown is in progress
+	shuttingDown atomic.Bool
+}
+
+type ActorSystemOption func(*ActorSystem)
+
+// WithDefaultMailbox sets the factory of the mailbox used by actors registered without WithMailbox
+func WithDefaultMailbox(factory MailboxFactory) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.mailboxFactory = factory
+	}
+}
+
+// WithDeadLetterCapacity sets the number of dead letters retained for inspection and replay, 0 to retain none
+func WithDeadLetterCapacity(capacity int) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.deadLetterCapacity = capacity
+	}
+}
+
+// WithOutboundMessageService enables the outbound message service over the given NATS connection, that is not closed on system shutdown
+func WithOutboundMessageService(
+	outboundArea string,
+	natsConnection *nats.Conn,
+	payloadTypeRegistry EnvelopePayloadTypeRegistry,
+) ActorSystemOption {
+	return WithNatsOutboundMessageService(outboundArea, payloadTypeRegistry, WithNatsConnection(natsConnection))
+}
+
+// WithNatsOutboundMessageService enables the outbound message service over a NATS connection configured by the given options.
+// The connection is dialed when the system is created and closed on system shutdown.
+func WithNatsOutboundMessageService(
+	outboundArea string,
+	payloadTypeRegistry EnvelopePayloadTypeRegistry,
+	opts ...NatsOption,
+) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.enableOutboundMessages = true
+
+		config := natsConfig{}
+		for _, opt := range opts {
+			opt(&config)
+		}
+
+		oo := OutboundOptions{
+			outboundArea: outboundArea,
+			typeRegistry: payloadTypeRegistry,
+			nats:         &config,
+		}
+		s.outboundOptions = &oo
+	}
+}
+
+// WithTransport enables the outbound message service over the given transport: the system receives the messages addressed to its outbound area.
+// The transport is not closed on system shutdown.
+func WithTransport(
+	outboundArea string,
+	transport Transport,
+	payloadTypeRegistry EnvelopePayloadTypeRegistry,
+) ActorSystemOption {
+	return func(s *ActorSystem) {
+		s.enableOutboundMessages = true
+
+		oo := OutboundOptions{
+			outboundArea: outboundArea,
+			transport:    transport,
+			typeRegistry: payloadTypeRegistry,
+		}
+		s.outboundOptions = &oo
+	}
+}
+
+// NewActorSystem creates a new actor system configured by the given options, it fails if the outbound message service can't be started
+func NewActorSystem(opts ...ActorSystemOption) (*ActorSystem, error) {
+	ctx, cancFunc := context.WithCancel(context.Background())
+
+	s := &ActorSystem{
+		registry:           newRegistry(),
+		context:            ctx,
+		cancelFunc:         cancFunc,
+		mailboxFactory:     defaultMailbox,
+		deadLetterCapacity: DefaultDeadLetterCapacity,
+		clock:              RealClock,
+		timers:             newTimerSet(),
+		remoteWatchers:     make(map[string]*remoteWatch),
+	}
+	s.events = newEventStream(s)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.startDeadLetterOffice()
+
+	if s.enableOutboundMessages {
+		err := s.startOutboundService()
+		if err != nil {
+			s.deadLetterActor.Drop()
+			cancFunc()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// startDeadLetterOffice activates the dead letter actor, that is not part of the registry of the system
+func (s *ActorSystem) startDeadLetterOffice() {
+	s.deadLetterOffice = newDeadLetterOffice(s, s.deadLetterCapacity)
+	s.deadLetterActor = &Actor{
+		address:        DeadLetterAddress,
+		system:         s,
+		stateProcessor: s.deadLetterOffice,
+		mailbox:        NewUnboundedMailbox(),
+		isClosed:       true,
+	}
+	s.deadLetterActor.Activate()
+}
+
+// DeadLetters returns the dead letter office of the system
+func (s *ActorSystem) DeadLetters() *DeadLetterOffice {
+	return s.deadLetterOffice
+}
+
+func (s *ActorSystem) GetContext() context.Context {
+	return s.context
+}
+
+// IsShutdown reports if the system has been shut down
+func (s *ActorSystem) IsShutdown() bool {
+	return s.context.Err() != nil
+}
+
+// RegisterActor creates an actor with the given address and state processor, adds it to the system and activates it
+func (s *ActorSystem) RegisterActor(address *Address, processor StateProcessor, opts ...ActorOption) (*Actor, error) {
+	return s.register(address, processor, nil, opts...)
+}
+
+// register creates and activates an actor, child of parent if not nil
+func (s *ActorSystem) register(address *Address, processor StateProcessor, parent *Actor, opts ...ActorOption) (*Actor, error) {
+	if address == nil || address.area == "" || address.id == "" {
+		return nil, ErrAddressInvalid
+	}
+	if address.IsEqual(DeadLetterAddress) {
+		return nil, ErrActorAddressAlreadyRegistered
+	}
+
+	a := Actor{
+		address:        address,
+		system:         s,
+		stateProcessor: processor,
+		parent:         parent,
+		isClosed:       true,
+	}
+
+	for _, opt := range opts {
+		opt(&a)
+	}
+
+	if a.mailbox == nil {
+		a.mailbox = s.mailboxFactory()
+	}
+
+	err := s.registry.add(&a)
+	if err != nil {
+		slog.Error(err.Error(), slog.String("actor-address", a.GetAddress().String()))
+		return nil, err
+	}
+
+	if parent != nil {
+		err = parent.addChild(&a)
+		if err != nil {
+			s.registry.remove(address, &a)
+			return nil, err
+		}
+	}
+
+	err = startProcessor(&a, processor)
+	if err != nil {
+		s.registry.remove(address, &a)
+		if parent != nil {
+			parent.removeChild(&a)
+		}
+		return nil, err
+	}
+
+	slog.Info("actor registered", slog.String("a", a.GetAddress().String()))
+	a.Activate()
+	if a.snapshotInterval > 0 {
+		_, err = a.Scheduler().Every(a.snapshotInterval, NewMessage(address, address, snapshotRequest{}))
+		if err != nil {
+			slog.Warn("actor periodic snapshot not scheduled", slog.String("address", address.String()), slog.String("err", err.Error()))
+		}
+	}
+	s.events.Publish(TopicActorRegistered, ActorRegistered{Address: address})
+	return &a, nil
+}
+
+func (s *ActorSystem) UnRegisterActor(address *Address) {
+	s.registry.remove(address, nil)
+}
+
+// SendMessage delivers the message to a local or remote actor; a message that can't be delivered is reported to the dead letter office
+// During a graceful shutdown only the actors can still send messages to the local actors not yet stopped: the message must have
+// a registered actor as From or carry the processing context of the sender.
+func (s *ActorSystem) SendMessage(msg Message) error {
+	if !msg.To.IsOutbound() && s.rejects(msg) && s.registry.get(msg.To) != nil {
+		s.deadLetter(msg, ErrInboxClosed)
+		return ErrInboxClosed
+	}
+	return s.sendInternal(msg)
+}
+
+func (s *ActorSystem) deliver(msg Message) error {
+	if msg.To.IsOutbound() {
+		return s.sendOutboundMessage(msg)
+	}
+	if msg.To != nil && msg.To.IsEqual(DeadLetterAddress) {
+		return s.deadLetterActor.post(msg)
+	}
+
+	actor := s.registry.get(msg.To)
+
+	if actor == nil {
+		slog.Error("actor not found", slog.String("actor-address", msg.To.String()))
+		return ErrActorNotFound
+	}
+
+	slog.Debug("actor found, sending msg", slog.String("actor-address", msg.To.String()))
+	err := actor.post(msg)
+	if err != nil {
+		slog.Error("actor inbox return error", slog.String("actor-address", msg.To.String()), slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// SendMessageContext sends the message carrying ctx, that the state processor receives
+func (s *ActorSystem) SendMessageContext(ctx context.Context, msg Message) error {
+	return s.SendMessage(msg.WithContext(ctx))
+}
+
+// Ask sends a message with response to an actor, local or remote, and waits for the returned message
+// within the message timeout or until the message context is done
+func (s *ActorSystem) Ask(msg Message) (Message, error) {
+	if msg.To.IsOutbound() {
+		return s.askOutboundMessage(msg)
+	}
+
+	actor := s.registry.get(msg.To)
+	if actor == nil {
+		slog.Error("actor not found", slog.String("actor-address", msg.To.String()))
+		return EmptyMessage, ErrActorNotFound
+	}
+
+	returnMsg, err := actor.InboxAndWaitResponse(msg)
+	if err != nil {
+		slog.Error(
+			"actor inbox with response return error",
+			slog.String("actor-address", msg.To.String()),
+			slog.String("error", err.Error()),
+		)
+		return EmptyMessage, err
+	}
+
+	return returnMsg, nil
+}
+
+// AskContext asks with a message carrying ctx, see Ask
+func (s *ActorSystem) AskContext(ctx context.Context, msg Message) (Message, error) {
+	return s.Ask(msg.WithContext(ctx))
+}
+
+// AskAs sends a message with response on the given system and checks that the returned body is of type T
+func AskAs[T any](s *ActorSystem, msg Message) (T, error) {
+	returnMsg, err := s.Ask(msg)
+	if err != nil {
+		return *new(T), err
+	}
+
+	if body, ok := returnMsg.Body.(T); ok {
+		return body, nil
+	}
+
+	return *new(T), ErrInboxReturnMessageBodyTypeWrong
+}
+
+func (s *ActorSystem) BroadcastMessage(msg Message, area *string) int {
+	counter := 0
+
+	for _, a := range s.registry.snapshot() {
+		if msg.From != nil && a.GetAddress().IsEqual(msg.From) {
+			continue
+		}
+
+		if area != nil && !a.GetAddress().IsSameArea(area) {
+			continue
+		}
+
+		err := a.Inbox(msg)
+		if err != nil {
+			slog.Warn("actor inbox error on broadcasting message", slog.String("actor-address", msg.To.String()), slog.String("error", err.Error()))
+			continue
+		}
+		counter++
+	}
+
+	return counter
+}
+
+// deadLetter reports a message that can't be delivered or processed to the dead letter office; the sender waiting for response receives the reason as error
+func (s *ActorSystem) deadLetter(msg Message, reason error) {
+	slog.Warn("dead letter", slog.String("msg", msg.String()), slog.String("reason", reason.Error()))
+	if msg.WithResponse && msg.ResponseChan != nil {
+		select {
+		case msg.ResponseChan <- NewReturnMessage(nil, msg, reason):
+		default:
+		}
+	}
+
+	if msg.To != nil && msg.To.IsEqual(DeadLetterAddress) {
+		return
+	}
+	letter := NewMessage(DeadLetterAddress, msg.From, DeadLetter{Message: msg, Reason: reason, At: time.Now()})
+	err := s.deadLetterActor.post(letter)
+	if err != nil {
+		slog.Debug("dead letter office not available", slog.String("err", err.Error()))
+	}
+}
+
+// Shutdown drops all actors without waiting for the pending messages, closes the outbound connection and cancels the system context.
+// See GracefulShutdown to process the pending messages before stopping.
+func (s *ActorSystem) Shutdown() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.GracefulShutdown(ctx)
+}
+
+// rejects reports if the system is shutting down and the message is not sent by one of its actors
+func (s *ActorSystem) rejects(msg Message) bool {
+	return s.shuttingDown.Load() && !s.sentByActor(msg)
+}
+
+// sentByActor reports if the message carries the processing context of an actor of the system, see ActorFromContext,
+// or the address of a registered actor as sender
+func (s *ActorSystem) sentByActor(msg Message) bool {
+	if a, ok := ActorFromContext(msg.Context()); ok && a.system == s {
+		return true
+	}
+	return msg.From != nil && !msg.From.IsOutbound() && s.registry.get(msg.From) != nil
+}
+
+// sendInternal delivers a message of the runtime, like a scheduled one, also during a graceful shutdown; see SendMessage
+func (s *ActorSystem) sendInternal(msg Message) error {
+	err := s.deliver(msg)
+	if err != nil {
+		s.deadLetter(msg, err)
+	}
+	return err
+}
+
+func (s *ActorSystem) NumActors() int {
+	return s.registry.len()
+}
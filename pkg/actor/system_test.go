@@ -0,0 +1,96 @@
+package actor_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorSystemsAreIsolated(t *testing.T) {
+	s1, _ := actor.NewActorSystem()
+	s2, _ := actor.NewActorSystem()
+	defer s1.Shutdown()
+	defer s2.Shutdown()
+
+	address := actor.NewAddress("test", "isolated")
+	counter1 := &atomic.Int64{}
+	counter2 := &atomic.Int64{}
+
+	_, err := s1.RegisterActor(address, &countingProcessor{counter1})
+	assert.NoError(t, err, "Failed to register actor on first system")
+	_, err = s2.RegisterActor(address, &countingProcessor{counter2})
+	assert.NoError(t, err, "Same address must be available on second system")
+
+	err = s1.SendMessage(actor.NewMessage(address, nil, "only first"))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return counter1.Load() == 1
+	}, 100*time.Millisecond, 10*time.Millisecond, "first system actor should receive the message")
+	assert.Equal(t, int64(0), counter2.Load(), "second system actor should not receive the message")
+	assert.Equal(t, 1, s1.NumActors())
+	assert.Equal(t, 1, s2.NumActors())
+}
+
+func TestActorSystemAsk(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	toAddr := actor.NewAddress("test", "receiver")
+	_, err := s.RegisterActor(toAddr, newMockProcessor())
+	assert.NoError(t, err)
+
+	msg := actor.NewMessageWithResponse(toAddr, nil, WithReturnTriggerMsgBody{Content: "ask"})
+	response, err := actor.AskAs[WithReturnTriggerMsgBodyReturn](s, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, WithReturnTriggerMsgBodyReturn("returned: ask"), response)
+
+	msg = actor.NewMessageWithResponse(toAddr, nil, WithReturnTriggerMsgBody{Content: "ask"})
+	_, err = actor.AskAs[string](s, msg)
+	assert.Equal(t, actor.ErrInboxReturnMessageBodyTypeWrong, err)
+}
+
+func TestActorSystemShutdown(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	_, err := s.RegisterActor(actor.NewAddress("test", "shutdown"), newMockProcessor())
+	assert.NoError(t, err)
+
+	assert.False(t, s.IsShutdown())
+	s.Shutdown()
+	assert.True(t, s.IsShutdown())
+	assert.Equal(t, 0, s.NumActors())
+}
+
+func TestActorSystemOutboundNotEnabled(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	msg := actor.NewMessage(actor.NewOutboundAddress("remote", "area", "id"), nil, "body")
+	err := s.SendMessage(msg)
+	assert.Equal(t, actor.ErrOutboundServiceNotEnabled, err)
+}
+
+func TestInitPostmanAfterShutdownAll(t *testing.T) {
+	first, _ := actor.InitPostman()
+	actor.ShutdownAll()
+	assert.True(t, first.IsShutdown())
+
+	second, _ := actor.InitPostman()
+	assert.NotSame(t, first, second, "a new default system is expected after shutdown")
+	assert.False(t, second.IsShutdown())
+	third, err := actor.InitPostman()
+	assert.NoError(t, err)
+	assert.Same(t, second, third, "default system is reused while alive")
+}
+
+func TestActorSystemAskOutboundNotEnabled(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	msg := actor.NewMessageWithResponse(actor.NewOutboundAddress("remote", "area", "id"), nil, "body")
+	_, err := s.Ask(msg)
+	assert.Equal(t, actor.ErrOutboundServiceNotEnabled, err)
+}
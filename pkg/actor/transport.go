@@ -0,0 +1,72 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTransportClosed         = errors.New("transport is closed")
+	ErrTransportRequestTimeout = errors.New("transport request has not been replied in time")
+	ErrTransportLinkLost       = errors.New("transport link with the other apps is lost")
+)
+
+// TransportMessage is a raw message exchanged by a transport: Reply is the subject where the receiver publishes the response of a request
+type TransportMessage struct {
+	Subject string
+	Reply   string
+	Data    []byte
+}
+
+type TransportHandler func(msg TransportMessage)
+
+type TransportSubscription interface {
+	Unsubscribe() error
+}
+
+// Transport delivers raw messages between apps by subject, as the outbound message service needs
+type Transport interface {
+	// Publish sends data to the subscribers of the subject
+	Publish(subject string, data []byte) error
+	// Subscribe calls handler for every message with subject matching the pattern ("*" matches a token, ">" matches the remaining tokens)
+	Subscribe(pattern string, handler TransportHandler) (TransportSubscription, error)
+	// Request publishes data with a reply subject and waits for the first response within timeout
+	Request(subject string, data []byte, timeout time.Duration) ([]byte, error)
+	Close() error
+}
+
+// TransportFlusher is implemented by transports buffering published messages: Flush is called on graceful shutdown
+type TransportFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// TransportLinkNotifier is implemented by transports that can lose the link with the other apps: f is called on every loss, with the cause if known
+type TransportLinkNotifier interface {
+	OnLinkLost(f func(err error))
+}
+
+// TransportLinkRestoreNotifier is implemented by transports that can restore a lost link: f is called on every restore
+type TransportLinkRestoreNotifier interface {
+	OnLinkRestored(f func())
+}
+
+// MatchSubject reports if the subject matches the pattern, with NATS wildcards: "*" matches one token and ">" the remaining ones
+func MatchSubject(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, AddressSeparator)
+	subjectTokens := strings.Split(subject, AddressSeparator)
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,224 @@
+package actor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nats-io/nuid"
+)
+
+const loopbackInboxPrefix = "_INBOX."
+
+// LoopbackNetwork connects the loopback transports created from it, like a NATS server does with its clients,
+// so apps with different outbound areas can exchange messages in a single process
+type LoopbackNetwork struct {
+	mutex         sync.RWMutex
+	subscriptions map[*loopbackSubscription]struct{}
+}
+
+func NewLoopbackNetwork() *LoopbackNetwork {
+	return &LoopbackNetwork{
+		subscriptions: make(map[*loopbackSubscription]struct{}),
+	}
+}
+
+// NewTransport returns a new transport connected to the network
+func (n *LoopbackNetwork) NewTransport() *LoopbackTransport {
+	return &LoopbackTransport{
+		network:       n,
+		subscriptions: make(map[*loopbackSubscription]struct{}),
+	}
+}
+
+func (n *LoopbackNetwork) deliver(msg TransportMessage) {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	for sub := range n.subscriptions {
+		if MatchSubject(sub.pattern, msg.Subject) {
+			sub.enqueue(msg)
+		}
+	}
+}
+
+func (n *LoopbackNetwork) add(sub *loopbackSubscription) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.subscriptions[sub] = struct{}{}
+}
+
+func (n *LoopbackNetwork) remove(sub *loopbackSubscription) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	delete(n.subscriptions, sub)
+}
+
+// loopbackSubscription delivers messages to its handler in order, on its own goroutine
+type loopbackSubscription struct {
+	pattern   string
+	transport *LoopbackTransport
+	mailbox   Mailbox
+	stop      chan struct{}
+	once      sync.Once
+}
+
+func (sub *loopbackSubscription) enqueue(msg TransportMessage) {
+	sub.mailbox.Post(NewMessage(nil, nil, msg))
+}
+
+func (sub *loopbackSubscription) run(handler TransportHandler) {
+	for {
+		msg, ok := sub.mailbox.Receive(sub.stop)
+		if !ok {
+			return
+		}
+		handler(msg.Body.(TransportMessage))
+	}
+}
+
+func (sub *loopbackSubscription) Unsubscribe() error {
+	sub.once.Do(func() {
+		sub.transport.network.remove(sub)
+		sub.transport.forget(sub)
+		sub.mailbox.Close()
+		close(sub.stop)
+	})
+	return nil
+}
+
+// LoopbackTransport is an in-memory Transport connected to a LoopbackNetwork
+type LoopbackTransport struct {
+	network          *LoopbackNetwork
+	mutex            sync.Mutex
+	subscriptions    map[*loopbackSubscription]struct{}
+	linkListeners    []func(err error)
+	restoreListeners []func()
+	closed           bool
+}
+
+func (t *LoopbackTransport) isClosed() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.closed
+}
+
+func (t *LoopbackTransport) forget(sub *loopbackSubscription) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.subscriptions, sub)
+}
+
+func (t *LoopbackTransport) Publish(subject string, data []byte) error {
+	return t.publish(TransportMessage{Subject: subject, Data: data})
+}
+
+func (t *LoopbackTransport) publish(msg TransportMessage) error {
+	if t.isClosed() {
+		return ErrTransportClosed
+	}
+	t.network.deliver(msg)
+	return nil
+}
+
+func (t *LoopbackTransport) Subscribe(pattern string, handler TransportHandler) (TransportSubscription, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil, ErrTransportClosed
+	}
+
+	sub := &loopbackSubscription{
+		pattern:   pattern,
+		transport: t,
+		mailbox:   NewUnboundedMailbox(),
+		stop:      make(chan struct{}),
+	}
+	t.subscriptions[sub] = struct{}{}
+	t.network.add(sub)
+	go sub.run(handler)
+	return sub, nil
+}
+
+func (t *LoopbackTransport) Request(subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	inbox := loopbackInboxPrefix + nuid.Next()
+	replies := make(chan []byte, 1)
+
+	sub, err := t.Subscribe(inbox, func(msg TransportMessage) {
+		select {
+		case replies <- msg.Data:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	err = t.publish(TransportMessage{Subject: subject, Reply: inbox, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-time.After(timeout):
+		return nil, ErrTransportRequestTimeout
+	}
+}
+
+func (t *LoopbackTransport) OnLinkLost(f func(err error)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.linkListeners = append(t.linkListeners, f)
+}
+
+// LoseLink reports a link loss with the given cause to the listeners, to test how apps react;
+// the transport keeps delivering messages, like a NATS connection that reconnects
+func (t *LoopbackTransport) LoseLink(err error) {
+	t.mutex.Lock()
+	listeners := append([]func(error){}, t.linkListeners...)
+	t.mutex.Unlock()
+
+	for _, f := range listeners {
+		f(err)
+	}
+}
+
+func (t *LoopbackTransport) OnLinkRestored(f func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.restoreListeners = append(t.restoreListeners, f)
+}
+
+// RestoreLink reports to the listeners that the link lost is restored
+func (t *LoopbackTransport) RestoreLink() {
+	t.mutex.Lock()
+	listeners := append([]func(){}, t.restoreListeners...)
+	t.mutex.Unlock()
+
+	for _, f := range listeners {
+		f()
+	}
+}
+
+// Close removes all the subscriptions of the transport from the network
+func (t *LoopbackTransport) Close() error {
+	t.mutex.Lock()
+	if t.closed {
+		t.mutex.Unlock()
+		return nil
+	}
+	t.closed = true
+	subs := make([]*loopbackSubscription, 0, len(t.subscriptions))
+	for sub := range t.subscriptions {
+		subs = append(subs, sub)
+	}
+	t.mutex.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	return nil
+}
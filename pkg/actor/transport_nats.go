@@ -0,0 +1,205 @@
+package actor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	ErrNatsServerNotConfigured = errors.New("nats server urls or connection must be configured")
+)
+
+// NatsTransport is the Transport adapter over a NATS connection
+type NatsTransport struct {
+	connection *nats.Conn
+}
+
+func NewNatsTransport(connection *nats.Conn) *NatsTransport {
+	return &NatsTransport{
+		connection: connection,
+	}
+}
+
+func (t *NatsTransport) Publish(subject string, data []byte) error {
+	return t.connection.Publish(subject, data)
+}
+
+func (t *NatsTransport) Subscribe(pattern string, handler TransportHandler) (TransportSubscription, error) {
+	return t.connection.Subscribe(pattern, func(msg *nats.Msg) {
+		handler(natsToTransportMessage(msg))
+	})
+}
+
+func (t *NatsTransport) Request(subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	msg, err := t.connection.Request(subject, data, timeout)
+	if err != nil {
+		if errors.Is(err, nats.ErrTimeout) {
+			return nil, ErrTransportRequestTimeout
+		}
+		return nil, err
+	}
+	return msg.Data, nil
+}
+
+// Flush waits until the published messages are processed by the server, within the deadline of ctx if any
+func (t *NatsTransport) Flush(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); ok {
+		return t.connection.FlushWithContext(ctx)
+	}
+	return t.connection.Flush()
+}
+
+// OnLinkLost calls f when the connection is disconnected from the server, after the disconnect handler already set on the connection
+func (t *NatsTransport) OnLinkLost(f func(err error)) {
+	previous := t.connection.DisconnectErrHandler()
+	t.connection.SetDisconnectErrHandler(func(nc *nats.Conn, err error) {
+		if previous != nil {
+			previous(nc, err)
+		}
+		f(err)
+	})
+}
+
+// OnLinkRestored calls f when the connection is reconnected to the server, after the reconnect handler already set on the connection
+func (t *NatsTransport) OnLinkRestored(f func()) {
+	previous := t.connection.ReconnectHandler()
+	t.connection.SetReconnectHandler(func(nc *nats.Conn) {
+		if previous != nil {
+			previous(nc)
+		}
+		f()
+	})
+}
+
+func (t *NatsTransport) Close() error {
+	t.connection.Close()
+	return nil
+}
+
+// Connection returns the underlying NATS connection
+func (t *NatsTransport) Connection() *nats.Conn {
+	return t.connection
+}
+
+func natsToTransportMessage(msg *nats.Msg) TransportMessage {
+	return TransportMessage{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Data:    msg.Data,
+	}
+}
+
+// natsConfig is the configuration of the NATS connection used by the outbound message service
+type natsConfig struct {
+	connection   *nats.Conn
+	urls         []string
+	nkeySeedFile string
+	options      []nats.Option
+}
+
+type NatsOption func(*natsConfig)
+
+// WithNatsConnection uses the given connection instead of dialing one: the connection is not closed on system shutdown
+func WithNatsConnection(connection *nats.Conn) NatsOption {
+	return func(c *natsConfig) {
+		c.connection = connection
+	}
+}
+
+// WithNatsURLs sets the servers to connect to
+func WithNatsURLs(urls ...string) NatsOption {
+	return func(c *natsConfig) {
+		c.urls = append(c.urls, urls...)
+	}
+}
+
+// WithNatsToken authenticates with a token
+func WithNatsToken(token string) NatsOption {
+	return WithNatsOptions(nats.Token(token))
+}
+
+// WithNatsUserPassword authenticates with user and password
+func WithNatsUserPassword(user, password string) NatsOption {
+	return WithNatsOptions(nats.UserInfo(user, password))
+}
+
+// WithNatsNkey authenticates with the nkey seed read from seedFile
+func WithNatsNkey(seedFile string) NatsOption {
+	return func(c *natsConfig) {
+		c.nkeySeedFile = seedFile
+	}
+}
+
+// WithNatsCredentials authenticates with the user JWT and nkey seed of a credentials file
+func WithNatsCredentials(credsFile string) NatsOption {
+	return WithNatsOptions(nats.UserCredentials(credsFile))
+}
+
+// WithNatsTLS connects with the given TLS configuration
+func WithNatsTLS(config *tls.Config) NatsOption {
+	return WithNatsOptions(nats.Secure(config))
+}
+
+// WithNatsTLSFiles connects with TLS using the client certificate and the root CA files, each one is optional
+func WithNatsTLSFiles(certFile, keyFile, caFile string) NatsOption {
+	return func(c *natsConfig) {
+		if certFile != "" && keyFile != "" {
+			c.options = append(c.options, nats.ClientCert(certFile, keyFile))
+		}
+		if caFile != "" {
+			c.options = append(c.options, nats.RootCAs(caFile))
+		}
+	}
+}
+
+// WithNatsReconnect sets the reconnect policy: maxReconnects attempts (negative for unlimited) waiting wait between them
+func WithNatsReconnect(maxReconnects int, wait time.Duration) NatsOption {
+	return WithNatsOptions(nats.MaxReconnects(maxReconnects), nats.ReconnectWait(wait))
+}
+
+// WithNatsOptions adds any other option of the NATS client
+func WithNatsOptions(opts ...nats.Option) NatsOption {
+	return func(c *natsConfig) {
+		c.options = append(c.options, opts...)
+	}
+}
+
+// connect returns the configured connection or dials a new one, reporting if the connection has been dialed
+func (c *natsConfig) connect() (*nats.Conn, bool, error) {
+	if c.connection != nil {
+		return c.connection, false, nil
+	}
+	if len(c.urls) == 0 {
+		return nil, false, ErrNatsServerNotConfigured
+	}
+
+	opts := []nats.Option{
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			slog.Warn("NATS connection lost", slog.Any("err", err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			slog.Info("NATS connection restored", slog.String("url", nc.ConnectedUrl()))
+		}),
+	}
+	if c.nkeySeedFile != "" {
+		nkey, err := nats.NkeyOptionFromSeed(c.nkeySeedFile)
+		if err != nil {
+			return nil, false, fmt.Errorf("nats nkey: %w", err)
+		}
+		opts = append(opts, nkey)
+	}
+	opts = append(opts, c.options...)
+
+	nc, err := nats.Connect(strings.Join(c.urls, ","), opts...)
+	if err != nil {
+		return nil, false, err
+	}
+	return nc, true, nil
+}
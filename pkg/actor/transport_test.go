@@ -0,0 +1,95 @@
+package actor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, c <-chan actor.TransportMessage) actor.TransportMessage {
+	select {
+	case msg := <-c:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message not received in time")
+	}
+	return actor.TransportMessage{}
+}
+
+func TestLoopbackTransportPublishSubscribe(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	t1 := network.NewTransport()
+	t2 := network.NewTransport()
+	defer t1.Close()
+	defer t2.Close()
+
+	received := make(chan actor.TransportMessage, 10)
+	_, err := t2.Subscribe("cinecity.app2.*.*", func(msg actor.TransportMessage) {
+		received <- msg
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, t1.Publish("cinecity.app2.local.actor", []byte("hello")))
+	assert.NoError(t, t1.Publish("cinecity.app3.local.actor", []byte("not for app2")))
+	assert.NoError(t, t1.Publish("cinecity.app2.local.actor", []byte("world")))
+
+	msg := receive(t, received)
+	assert.Equal(t, "cinecity.app2.local.actor", msg.Subject)
+	assert.Equal(t, "hello", string(msg.Data))
+	assert.Equal(t, "world", string(receive(t, received).Data), "messages must be delivered in order")
+
+	select {
+	case msg := <-received:
+		t.Errorf("unexpected message %s", msg.Subject)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestLoopbackTransportRequest(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	requester := network.NewTransport()
+	responder := network.NewTransport()
+	defer requester.Close()
+	defer responder.Close()
+
+	_, err := responder.Subscribe("echo", func(msg actor.TransportMessage) {
+		responder.Publish(msg.Reply, append([]byte("echo: "), msg.Data...))
+	})
+	assert.NoError(t, err)
+
+	reply, err := requester.Request("echo", []byte("hello"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo: hello", string(reply))
+
+	_, err = requester.Request("nobody", []byte("hello"), 20*time.Millisecond)
+	assert.Equal(t, actor.ErrTransportRequestTimeout, err)
+}
+
+func TestLoopbackTransportUnsubscribeAndClose(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	transport := network.NewTransport()
+
+	received := make(chan actor.TransportMessage, 10)
+	sub, err := transport.Subscribe("topic.>", func(msg actor.TransportMessage) {
+		received <- msg
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, transport.Publish("topic.a.b", []byte("1")))
+	receive(t, received)
+
+	assert.NoError(t, sub.Unsubscribe())
+	assert.NoError(t, transport.Publish("topic.a.b", []byte("2")))
+	select {
+	case <-received:
+		t.Error("unsubscribed handler must not receive messages")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.NoError(t, transport.Close())
+	assert.Equal(t, actor.ErrTransportClosed, transport.Publish("topic.a", nil))
+	_, err = transport.Subscribe("topic.a", func(msg actor.TransportMessage) {})
+	assert.Equal(t, actor.ErrTransportClosed, err)
+}
@@ -0,0 +1,40 @@
+package actor
+
+import (
+	"context"
+)
+
+// Ref is a typed reference to an actor: only bodies of type Req can be sent through it
+type Ref[Req any] struct {
+	system  *ActorSystem
+	address *Address
+}
+
+// NewRef returns a typed reference to the actor with the given address, local or remote, of the system
+func NewRef[Req any](s *ActorSystem, address *Address) Ref[Req] {
+	return Ref[Req]{
+		system:  s,
+		address: address,
+	}
+}
+
+// RefOf returns a typed reference to the actor
+func RefOf[Req any](a *Actor) Ref[Req] {
+	return NewRef[Req](a.system, a.address)
+}
+
+func (r Ref[Req]) Address() *Address {
+	return r.address
+}
+
+// Send sends the body to the actor carrying ctx, from is optional
+func (r Ref[Req]) Send(ctx context.Context, from *Address, body Req) error {
+	return r.system.SendMessageContext(ctx, NewMessage(r.address, from, body))
+}
+
+// Ask sends the body to the actor referenced by ref and waits for a response body of type Resp,
+// until ctx is done or the default message timeout expires
+func Ask[Req any, Resp any](ctx context.Context, ref Ref[Req], from *Address, body Req) (Resp, error) {
+	msg := NewMessageWithResponse(ref.address, from, body)
+	return AskAs[Resp](ref.system, msg.WithContext(ctx))
+}
@@ -0,0 +1,184 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrActorDropped   = errors.New("actor dropped")
+	ErrActorRestarted = errors.New("actor restarted")
+)
+
+// Terminated is sent to the watchers of an actor when it is dropped, restarted or its state processor panics, with From set to the watched actor.
+// Reason is ErrActorDropped, ErrActorRestarted or ErrProcessorPanic wrapping the failure, ErrActorNotFound if the actor was not registered
+// when watched, ErrTransportLinkLost for a remote actor.
+// Only a dropped actor, a not found one or a remote one is no more watched after the notification.
+type Terminated struct {
+	Address *Address
+	Reason  error
+}
+
+// remoteWatch are the watchers of a remote actor, notified when the transport link is lost
+type remoteWatch struct {
+	target   *Address
+	watchers []*Address
+}
+
+// Watch makes watcher receive Terminated when the target actor, local or remote, terminates
+func (s *ActorSystem) Watch(watcher, target *Address) error {
+	if watcher == nil || target == nil {
+		return ErrAddressInvalid
+	}
+
+	if target.IsOutbound() {
+		if !s.isOutboundEnabled() {
+			return ErrOutboundServiceNotEnabled
+		}
+		s.watchMutex.Lock()
+		key := target.String()
+		rw, ok := s.remoteWatchers[key]
+		if !ok {
+			rw = &remoteWatch{target: target}
+			s.remoteWatchers[key] = rw
+		}
+		rw.watchers = appendAddress(rw.watchers, watcher)
+		s.watchMutex.Unlock()
+	} else {
+		a := s.registry.get(target)
+		if a == nil || !a.addWatcher(watcher) {
+			s.notifyTerminated(watcher, target, ErrActorNotFound)
+			return nil
+		}
+	}
+
+	if w := s.registry.get(watcher); w != nil {
+		w.mutex.Lock()
+		w.watching = appendAddress(w.watching, target)
+		w.mutex.Unlock()
+	}
+	return nil
+}
+
+// Unwatch stops the notification of the termination of target to watcher
+func (s *ActorSystem) Unwatch(watcher, target *Address) {
+	if watcher == nil || target == nil {
+		return
+	}
+	s.unwatchTarget(watcher, target)
+
+	if w := s.registry.get(watcher); w != nil {
+		w.mutex.Lock()
+		w.watching = removeAddress(w.watching, target)
+		w.mutex.Unlock()
+	}
+}
+
+func (s *ActorSystem) unwatchTarget(watcher, target *Address) {
+	if target.IsOutbound() {
+		s.watchMutex.Lock()
+		defer s.watchMutex.Unlock()
+		key := target.String()
+		if rw, ok := s.remoteWatchers[key]; ok {
+			rw.watchers = removeAddress(rw.watchers, watcher)
+			if len(rw.watchers) == 0 {
+				delete(s.remoteWatchers, key)
+			}
+		}
+		return
+	}
+
+	if a := s.registry.get(target); a != nil {
+		a.mutex.Lock()
+		a.watchers = removeAddress(a.watchers, watcher)
+		a.mutex.Unlock()
+	}
+}
+
+// remoteLinkLost notifies the watchers of all the remote actors, that are no more watched
+func (s *ActorSystem) remoteLinkLost(err error) {
+	reason := ErrTransportLinkLost
+	if err != nil {
+		reason = fmt.Errorf("%w: %w", ErrTransportLinkLost, err)
+	}
+
+	s.events.Publish(TopicRemoteLinkDown, RemoteLinkDown{Area: s.outboundOptions.outboundArea, Reason: reason})
+
+	s.watchMutex.Lock()
+	watches := s.remoteWatchers
+	s.remoteWatchers = make(map[string]*remoteWatch)
+	s.watchMutex.Unlock()
+
+	for _, rw := range watches {
+		for _, watcher := range rw.watchers {
+			if w := s.registry.get(watcher); w != nil {
+				w.mutex.Lock()
+				w.watching = removeAddress(w.watching, rw.target)
+				w.mutex.Unlock()
+			}
+			s.notifyTerminated(watcher, rw.target, reason)
+		}
+	}
+}
+
+// remoteLinkRestored publishes that the transport is linked again to the other apps
+func (s *ActorSystem) remoteLinkRestored() {
+	s.events.Publish(TopicRemoteLinkUp, RemoteLinkUp{Area: s.outboundOptions.outboundArea})
+}
+
+// notifyTerminated delivers Terminated to the watcher: a watcher that can't be reached does not produce a dead letter
+func (s *ActorSystem) notifyTerminated(watcher, target *Address, reason error) {
+	err := s.deliver(NewMessage(watcher, target, Terminated{Address: target, Reason: reason}))
+	if err != nil {
+		slog.Debug("watcher not reachable", slog.String("watcher", watcher.String()), slog.String("err", err.Error()))
+	}
+}
+
+// Watch makes the actor receive Terminated when the target actor terminates, see ActorSystem.Watch
+func (a *Actor) Watch(target *Address) error {
+	return a.system.Watch(a.address, target)
+}
+
+// Unwatch stops watching the target actor
+func (a *Actor) Unwatch(target *Address) {
+	a.system.Unwatch(a.address, target)
+}
+
+func (a *Actor) addWatcher(watcher *Address) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.isDropped {
+		return false
+	}
+	a.watchers = appendAddress(a.watchers, watcher)
+	return true
+}
+
+func (a *Actor) notifyWatchers(reason error) {
+	a.mutex.RLock()
+	watchers := append([]*Address{}, a.watchers...)
+	a.mutex.RUnlock()
+
+	for _, watcher := range watchers {
+		a.system.notifyTerminated(watcher, a.address, reason)
+	}
+}
+
+func appendAddress(addresses []*Address, address *Address) []*Address {
+	for _, addr := range addresses {
+		if addr.IsEqual(address) && addr.IsOutbound() == address.IsOutbound() {
+			return addresses
+		}
+	}
+	return append(addresses, address)
+}
+
+func removeAddress(addresses []*Address, address *Address) []*Address {
+	for i, addr := range addresses {
+		if addr.IsEqual(address) && addr.IsOutbound() == address.IsOutbound() {
+			return append(addresses[:i], addresses[i+1:]...)
+		}
+	}
+	return addresses
+}
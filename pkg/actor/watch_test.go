@@ -0,0 +1,164 @@
+package actor_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// terminationCollector forwards the Terminated messages it receives to its channel
+type terminationCollector struct {
+	terminated chan actor.Terminated
+}
+
+func newTerminationCollector() *terminationCollector {
+	return &terminationCollector{terminated: make(chan actor.Terminated, 10)}
+}
+
+func (c *terminationCollector) Process(msg actor.Message) {
+	if t, ok := msg.Body.(actor.Terminated); ok {
+		c.terminated <- t
+	}
+}
+
+func (c *terminationCollector) Shutdown() {}
+
+func (c *terminationCollector) GetState() any {
+	return nil
+}
+
+func receiveTerminated(t *testing.T, c <-chan actor.Terminated) actor.Terminated {
+	select {
+	case terminated := <-c:
+		return terminated
+	case <-time.After(time.Second):
+		t.Fatal("terminated not received in time")
+	}
+	return actor.Terminated{}
+}
+
+func setupWatcher(t *testing.T, s *actor.ActorSystem) (*actor.Actor, *terminationCollector) {
+	collector := newTerminationCollector()
+	watcher, err := s.RegisterActor(actor.NewAddress("local", "watcher"), collector)
+	assert.NoError(t, err)
+	return watcher, collector
+}
+
+func TestWatchDroppedActor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	watcher, collector := setupWatcher(t, s)
+	target, err := s.RegisterActor(actor.NewAddress("local", "target"), &countingProcessor{&atomic.Int64{}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, watcher.Watch(target.GetAddress()))
+	assert.NoError(t, watcher.Watch(target.GetAddress()), "watching twice notifies once")
+	target.Drop()
+
+	terminated := receiveTerminated(t, collector.terminated)
+	assert.True(t, terminated.Address.IsEqual(target.GetAddress()))
+	assert.Equal(t, actor.ErrActorDropped, terminated.Reason)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, collector.terminated)
+
+	assert.NoError(t, watcher.Watch(actor.NewAddress("local", "missing")))
+	assert.Equal(t, actor.ErrActorNotFound, receiveTerminated(t, collector.terminated).Reason, "watching a missing actor notifies at once")
+
+	assert.Equal(t, actor.ErrAddressInvalid, s.Watch(nil, target.GetAddress()))
+	assert.Equal(t, actor.ErrOutboundServiceNotEnabled, s.Watch(watcher.GetAddress(), actor.NewOutboundAddress("app2", "local", "actor")))
+}
+
+func TestUnwatch(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	watcher, collector := setupWatcher(t, s)
+	target, err := s.RegisterActor(actor.NewAddress("local", "target"), &countingProcessor{&atomic.Int64{}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, watcher.Watch(target.GetAddress()))
+	watcher.Unwatch(target.GetAddress())
+	target.Drop()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, collector.terminated)
+}
+
+func TestWatchDroppedWatcherIsForgotten(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	watcher, _ := setupWatcher(t, s)
+	target, err := s.RegisterActor(actor.NewAddress("local", "target"), &countingProcessor{&atomic.Int64{}})
+	assert.NoError(t, err)
+	assert.NoError(t, watcher.Watch(target.GetAddress()))
+	watcher.Drop()
+
+	collector := newTerminationCollector()
+	_, err = s.RegisterActor(actor.NewAddress("local", "watcher"), collector)
+	assert.NoError(t, err)
+	target.Drop()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, collector.terminated, "a new actor at the address of a dropped watcher must not be notified")
+}
+
+func TestWatchCrashedAndRestartedActor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	watcher, collector := setupWatcher(t, s)
+	crashing, err := s.RegisterActor(actor.NewAddress("local", "fragile"), &fragileProcessor{processed: &atomic.Int64{}})
+	assert.NoError(t, err)
+	assert.NoError(t, watcher.Watch(crashing.GetAddress()))
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(crashing.GetAddress(), nil, PanicBody("boom"))))
+	terminated := receiveTerminated(t, collector.terminated)
+	assert.ErrorIs(t, terminated.Reason, actor.ErrProcessorPanic)
+	assert.Contains(t, terminated.Reason.Error(), "boom")
+
+	spec, _ := fragileChild("child")
+	_, err = s.RegisterActor(actor.NewAddress("test", "supervisor"), actor.NewSupervisor([]actor.ChildSpec{spec}))
+	assert.NoError(t, err)
+	assert.NoError(t, watcher.Watch(spec.Address))
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("bang"))))
+	terminated = receiveTerminated(t, collector.terminated)
+	assert.True(t, terminated.Address.IsEqual(spec.Address))
+	assert.ErrorIs(t, terminated.Reason, actor.ErrActorRestarted)
+	assert.Contains(t, terminated.Reason.Error(), "bang")
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(spec.Address, nil, PanicBody("bang again"))))
+	assert.ErrorIs(t, receiveTerminated(t, collector.terminated).Reason, actor.ErrActorRestarted, "a restarted actor is still watched")
+}
+
+func TestWatchRemoteActorOnLinkLost(t *testing.T) {
+	network := actor.NewLoopbackNetwork()
+	transport := network.NewTransport()
+	s, err := actor.NewActorSystem(actor.WithTransport("app1", transport, remoteRegistry))
+	assert.NoError(t, err)
+	defer s.Shutdown()
+
+	watcher, collector := setupWatcher(t, s)
+	remote := actor.NewOutboundAddress("app2", "local", "actor")
+	other := actor.NewOutboundAddress("app2", "local", "other")
+	assert.NoError(t, watcher.Watch(remote))
+	assert.NoError(t, watcher.Watch(other))
+	watcher.Unwatch(other)
+
+	linkErr := errors.New("connection reset")
+	transport.LoseLink(linkErr)
+	terminated := receiveTerminated(t, collector.terminated)
+	assert.Equal(t, remote.String(), terminated.Address.String())
+	assert.ErrorIs(t, terminated.Reason, actor.ErrTransportLinkLost)
+	assert.ErrorIs(t, terminated.Reason, linkErr)
+
+	transport.LoseLink(nil)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, collector.terminated, "remote actors are no more watched after the link loss")
+}
@@ -1,6 +1,7 @@
 package batch
 
 import (
+	"errors"
 	"log/slog"
 	"sync"
 	"time"
@@ -8,55 +9,371 @@ import (
 	"github.com/pix303/cinecity/pkg/actor"
 )
 
-type Batcher struct {
-	messages         []actor.Message
-	maxNumMessages   uint
-	timeout          time.Duration
-	timer            *time.Timer
-	mutex            sync.Mutex
-	processMessageFn func(actor.Message)
+var (
+	ErrBatcherClosed = errors.New("batcher is closed")
+)
+
+// Batcher collects items and delivers them in batches to its handler when a batch is complete: full by number of items or bytes,
+// or expired by timeout or debounce. It is safe for concurrent use and the handler is never called concurrently,
+// with batches in the order they are completed.
+type Batcher[T any] struct {
+	config[T]
+	handler      func([]T)
+	mutex        sync.Mutex
+	deliverMutex sync.Mutex
+	items        []T
+	sizes        []int
+	bytes        int
+	// keys maps the key of an item to its index in items, when the items are grouped by key
+	keys      map[any]int
+	timer     actor.ClockTimer
+	idleTimer actor.ClockTimer
+	// generation identifies the current batch, so a timer of a batch already completed is ignored
+	generation uint64
+	// completed holds the batches waiting to be delivered by the throttle
+	completed     [][]T
+	lastDelivery  time.Time
+	throttleTimer actor.ClockTimer
+	closed        bool
 }
 
-type MessageProcessHandler = func(msg actor.Message)
+// config holds the settings of a batcher of items of type T
+type config[T any] struct {
+	maxItems int
+	maxBytes int
+	sizeOf   func(T) int
+	keyOf    func(T) any
+	timeout  time.Duration
+	debounce time.Duration
+	throttle time.Duration
+	clock    actor.Clock
+}
+
+// Option configures a batcher of items of type T; the type is inferred from the arguments of WithMaxBytes and WithKey,
+// and must be given to the other options, e.g. WithMaxItems[string](10)
+type Option[T any] func(*config[T])
+
+// WithMaxItems completes the batch when it reaches n items, 0 for no limit
+func WithMaxItems[T any](n int) Option[T] {
+	return func(c *config[T]) {
+		c.maxItems = n
+	}
+}
+
+// WithMaxBytes completes the batch before it exceeds n bytes, as measured by sizer; an item bigger than n is a batch on its own
+func WithMaxBytes[T any](n int, sizer func(T) int) Option[T] {
+	return func(c *config[T]) {
+		c.maxBytes = n
+		c.sizeOf = sizer
+	}
+}
+
+// WithKey groups the items by the key returned by key, keeping the last item per key in the position of the first one.
+// Max items counts the distinct keys.
+func WithKey[T any, K comparable](key func(T) K) Option[T] {
+	return func(c *config[T]) {
+		c.keyOf = func(item T) any {
+			return key(item)
+		}
+	}
+}
+
+// WithTimeout completes the batch when timeout has elapsed since its first item, 0 to complete it only when full or flushed.
+// With WithDebounce it is the max wait of the batch.
+func WithTimeout[T any](timeout time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.timeout = timeout
+	}
+}
+
+// WithDebounce completes the batch when no item has been added for the given duration: the timer restarts on every Add
+func WithDebounce[T any](d time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.debounce = d
+	}
+}
+
+// WithThrottle delivers at most one batch per interval: the batches completed in between wait for their turn.
+// Flush and Close deliver at once.
+func WithThrottle[T any](interval time.Duration) Option[T] {
+	return func(c *config[T]) {
+		c.throttle = interval
+	}
+}
+
+// WithClock sets the clock of the timers, actor.RealClock by default
+func WithClock[T any](clock actor.Clock) Option[T] {
+	return func(c *config[T]) {
+		c.clock = clock
+	}
+}
 
-func NewBatcher(timeoutMs uint, maxMessages uint, fn MessageProcessHandler) *Batcher {
-	b := Batcher{
-		timeout:          time.Duration(timeoutMs) * time.Millisecond,
-		messages:         make([]actor.Message, 0),
-		mutex:            sync.Mutex{},
-		maxNumMessages:   maxMessages,
-		processMessageFn: fn,
+// New creates a batcher delivering the batches to handler; the handler must not add items to the same batcher
+func New[T any](handler func([]T), opts ...Option[T]) *Batcher[T] {
+	b := &Batcher[T]{
+		config: config[T]{
+			clock: actor.RealClock,
+		},
+		handler: handler,
+	}
+	for _, opt := range opts {
+		opt(&b.config)
+	}
+	if b.keyOf != nil {
+		b.keys = make(map[any]int)
 	}
+	return b
+}
+
+// BatchReady is the message body posted to the owning actor by a batcher created with NewForActor
+type BatchReady[T any] struct {
+	Items []T
+}
+
+// NewForActor creates a batcher that posts every batch to the actor as a BatchReady message, so the batch is handled by the
+// state processor in Process, without concurrent access to the state. The batcher is bound to the actor (see Bind): on a graceful
+// shutdown the pending items are posted before the mailbox is drained, so they are handled too, while a batch that can't be posted,
+// e.g. because the actor is dropped, is reported to the dead letter office.
+// Batches are posted with Actor.PostSelf, so a batch completed by Add in Process does not block on a full mailbox of the actor.
+func NewForActor[T any](a *actor.Actor, opts ...Option[T]) *Batcher[T] {
+	b := New(func(items []T) {
+		err := a.PostSelf(BatchReady[T]{Items: items})
+		if err != nil {
+			slog.Warn("batch not posted to actor", slog.String("actor-address", a.GetAddress().String()), slog.String("err", err.Error()))
+		}
+	}, opts...)
+	b.Bind(a)
+	return b
+}
+
+// MessageBatcher is the batcher of actor messages created by NewBatcher
+type MessageBatcher = Batcher[actor.Message]
+
+type MessageProcessHandler = func(msg actor.Message)
+
+// NewBatcher creates a batcher of messages calling fn for every message of a batch, see New
+func NewBatcher(timeoutMs uint, maxMessages uint, fn MessageProcessHandler) *MessageBatcher {
 	slog.Info("Batcher created", "timeout", timeoutMs, "maxMessages", maxMessages)
-	return &b
+	return New(
+		func(messages []actor.Message) {
+			for _, msg := range messages {
+				fn(msg)
+			}
+		},
+		WithTimeout[actor.Message](time.Duration(timeoutMs)*time.Millisecond),
+		WithMaxItems[actor.Message](max(int(maxMessages), 1)),
+	)
 }
 
-func (batcher *Batcher) Add(msg actor.Message) {
-	batcher.mutex.Lock()
-	if len(batcher.messages) == 0 {
-		slog.Info("batch timer started", slog.Time("time", time.Now()), slog.Duration("timeout", batcher.timeout))
-		batcher.timer = time.AfterFunc(batcher.timeout, batcher.process)
+// Add appends the item to the current batch, delivering the batches completed; it fails with ErrBatcherClosed after Close
+func (b *Batcher[T]) Add(item T) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return ErrBatcherClosed
 	}
-	batcher.messages = append(batcher.messages, msg)
-	slog.Info("batch msg added", slog.Int("totalMsg", len(batcher.messages)), slog.Int("max", int(batcher.maxNumMessages)))
-	batcher.mutex.Unlock()
 
-	if len(batcher.messages) >= int(batcher.maxNumMessages) {
-		slog.Info("batch max messages reached")
-		batcher.process()
+	size := 0
+	if b.sizeOf != nil {
+		size = b.sizeOf(item)
+	}
+	if !b.replaceLocked(item, size) {
+		if b.maxBytes > 0 && len(b.items) > 0 && b.bytes+size > b.maxBytes {
+			b.completeLocked()
+		}
+		b.appendLocked(item, size)
+	}
+
+	if (b.maxItems > 0 && len(b.items) >= b.maxItems) || (b.maxBytes > 0 && b.bytes >= b.maxBytes) {
+		b.completeLocked()
+	} else {
+		b.debounceLocked()
+	}
+	b.deliverLocked(b.releaseLocked())
+	return nil
+}
+
+// replaceLocked replaces the item with the same key in the current batch, if any; the caller must hold the lock
+func (b *Batcher[T]) replaceLocked(item T, size int) bool {
+	if b.keyOf == nil {
+		return false
+	}
+	i, ok := b.keys[b.keyOf(item)]
+	if !ok {
+		return false
 	}
+	b.items[i] = item
+	b.bytes += size - b.sizes[i]
+	b.sizes[i] = size
+	return true
+}
+
+// appendLocked appends the item to the current batch, starting its timeout on the first one; the caller must hold the lock
+func (b *Batcher[T]) appendLocked(item T, size int) {
+	if len(b.items) == 0 && b.timeout > 0 {
+		generation := b.generation
+		b.timer = b.clock.AfterFunc(b.timeout, func() {
+			b.expire(generation)
+		})
+	}
+	b.items = append(b.items, item)
+	b.sizes = append(b.sizes, size)
+	b.bytes += size
+	if b.keys != nil {
+		b.keys[b.keyOf(item)] = len(b.items) - 1
+	}
+}
+
+// debounceLocked restarts the debounce timer of the current batch; the caller must hold the lock
+func (b *Batcher[T]) debounceLocked() {
+	if b.debounce <= 0 || len(b.items) == 0 {
+		return
+	}
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+	}
+	generation := b.generation
+	b.idleTimer = b.clock.AfterFunc(b.debounce, func() {
+		b.expire(generation)
+	})
+}
+
+// expire completes and delivers the batch whose timer is elapsed, if not already completed
+func (b *Batcher[T]) expire(generation uint64) {
+	b.mutex.Lock()
+	if generation == b.generation {
+		b.completeLocked()
+	}
+	b.deliverLocked(b.releaseLocked())
+}
+
+// completeLocked moves the current batch, if not empty, to the completed ones and stops its timers; the caller must hold the lock
+func (b *Batcher[T]) completeLocked() {
+	if len(b.items) > 0 {
+		b.completed = append(b.completed, b.items)
+	}
+	b.items = nil
+	b.sizes = nil
+	b.bytes = 0
+	clear(b.keys)
+	b.generation++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+}
+
+// releaseLocked takes the completed batches that can be delivered now, scheduling the next one when throttled;
+// the caller must hold the lock
+func (b *Batcher[T]) releaseLocked() [][]T {
+	if len(b.completed) == 0 {
+		return nil
+	}
+	if b.throttle <= 0 {
+		return b.takeLocked()
+	}
+	if b.throttleTimer != nil {
+		return nil
+	}
+
+	now := b.clock.Now()
+	if wait := b.lastDelivery.Add(b.throttle).Sub(now); !b.lastDelivery.IsZero() && wait > 0 {
+		b.throttleTimer = b.clock.AfterFunc(wait, b.unthrottle)
+		return nil
+	}
+	ready := [][]T{b.completed[0]}
+	b.completed = b.completed[1:]
+	b.lastDelivery = now
+	if len(b.completed) > 0 {
+		b.throttleTimer = b.clock.AfterFunc(b.throttle, b.unthrottle)
+	}
+	return ready
+}
+
+// takeLocked takes all the completed batches; the caller must hold the lock
+func (b *Batcher[T]) takeLocked() [][]T {
+	ready := b.completed
+	b.completed = nil
+	if b.throttleTimer != nil {
+		b.throttleTimer.Stop()
+		b.throttleTimer = nil
+	}
+	return ready
+}
+
+// unthrottle delivers the next completed batch when the throttle interval is elapsed
+func (b *Batcher[T]) unthrottle() {
+	b.mutex.Lock()
+	b.throttleTimer = nil
+	b.deliverLocked(b.releaseLocked())
+}
+
+// deliverLocked calls the handler with the batches; the caller must hold the lock, that is released.
+// The delivery lock is acquired before releasing the lock, so batches are delivered in order.
+func (b *Batcher[T]) deliverLocked(batches [][]T) {
+	if len(batches) == 0 {
+		b.mutex.Unlock()
+		return
+	}
+	b.deliverMutex.Lock()
+	b.mutex.Unlock()
+	defer b.deliverMutex.Unlock()
+
+	for _, items := range batches {
+		b.handler(items)
+	}
+}
+
+// flushLocked completes the current batch and delivers all the completed ones, ignoring the throttle;
+// the caller must hold the lock, that is released
+func (b *Batcher[T]) flushLocked() {
+	b.completeLocked()
+	ready := b.takeLocked()
+	if len(ready) > 0 && b.throttle > 0 {
+		b.lastDelivery = b.clock.Now()
+	}
+	b.deliverLocked(ready)
+}
+
+// Flush delivers the pending items without waiting for the batch to be complete or for the throttle
+func (b *Batcher[T]) Flush() {
+	b.mutex.Lock()
+	b.flushLocked()
+}
+
+// Close delivers the pending items and rejects the next ones; it is safe to call it more than once
+func (b *Batcher[T]) Close() {
+	b.mutex.Lock()
+	b.closed = true
+	b.flushLocked()
+}
+
+// Stop discards the pending items without delivering them
+func (b *Batcher[T]) Stop() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.completeLocked()
+	b.takeLocked()
 }
 
-func (batcher *Batcher) process() {
-	slog.Info("batch process started", slog.Time("time", time.Now()))
-	for _, msg := range batcher.messages {
-		batcher.processMessageFn(msg)
+// Len returns the number of pending items, in the current batch and in the completed ones waiting for the throttle
+func (b *Batcher[T]) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	n := len(b.items)
+	for _, items := range b.completed {
+		n += len(items)
 	}
-	batcher.Stop()
-	slog.Info("batch process end")
+	return n
 }
 
-func (batcher *Batcher) Stop() {
-	batcher.timer.Stop()
-	batcher.messages = make([]actor.Message, 0)
+// Bind ties the batcher to the lifecycle of the actor: the pending items are delivered when a graceful shutdown drains the actor
+// (see Actor.OnDrain) and the batcher is closed, delivering the pending items, when the actor is dropped
+func (b *Batcher[T]) Bind(a *actor.Actor) {
+	a.OnDrain(b.Flush)
+	a.OnDrop(b.Close)
 }
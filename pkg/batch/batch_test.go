@@ -1,7 +1,10 @@
 package batch_test
 
 import (
+	"context"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -141,3 +144,376 @@ func TestBatcher_StateDontChangesBeforMaxItemsLimits(t *testing.T) {
 	assert.IsType(t, FirstMessage(""), fixtureState[0].Body, "It must be persisted the first message sended")
 	assert.IsType(t, SecondMessage(""), fixtureState[1].Body, "It must be persisted the second message sended")
 }
+
+func TestBatcher_FlushOnShutdown(t *testing.T) {
+	msg1, msg2, handler := setup()
+
+	s, _ := actor.NewActorSystem()
+	b := batch.NewBatcher(1000, 100, handler)
+	s.OnShutdown(func(ctx context.Context) error {
+		b.Flush()
+		return nil
+	})
+	b.Add(msg1)
+	b.Add(msg2)
+
+	_, err := s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(fixtureState), "It must be persisted 2 messages on shutdown")
+}
+
+var batchStart = time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC)
+
+// batchRecorder records the batches delivered by a batcher
+type batchRecorder[T any] struct {
+	mutex   sync.Mutex
+	batches [][]T
+}
+
+func (r *batchRecorder[T]) handle(items []T) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.batches = append(r.batches, items)
+}
+
+func (r *batchRecorder[T]) get() [][]T {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([][]T{}, r.batches...)
+}
+
+func TestBatcherDeliversWholeBatches(t *testing.T) {
+	clock := actor.NewManualClock(batchStart)
+	recorder := &batchRecorder[int]{}
+	b := batch.New(recorder.handle, batch.WithMaxItems[int](3), batch.WithTimeout[int](time.Second), batch.WithClock[int](clock))
+
+	for i := range 4 {
+		assert.NoError(t, b.Add(i))
+	}
+	assert.Equal(t, [][]int{{0, 1, 2}}, recorder.get(), "a full batch is delivered at once")
+	assert.Equal(t, 1, b.Len())
+
+	clock.Advance(999 * time.Millisecond)
+	assert.Len(t, recorder.get(), 1)
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, [][]int{{0, 1, 2}, {3}}, recorder.get(), "timeout starts with the first item of the batch")
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestBatcherFlushCloseAndStop(t *testing.T) {
+	clock := actor.NewManualClock(batchStart)
+	recorder := &batchRecorder[string]{}
+	b := batch.New(recorder.handle, batch.WithTimeout[string](time.Second), batch.WithClock[string](clock))
+
+	assert.NoError(t, b.Add("a"))
+	b.Flush()
+	b.Flush()
+	assert.Equal(t, [][]string{{"a"}}, recorder.get(), "an empty batch is not delivered")
+	assert.Equal(t, 0, clock.Pending(), "timer of a flushed batch is stopped")
+
+	assert.NoError(t, b.Add("b"))
+	b.Stop()
+	assert.Equal(t, 0, b.Len())
+
+	assert.NoError(t, b.Add("c"))
+	b.Close()
+	b.Close()
+	assert.Equal(t, [][]string{{"a"}, {"c"}}, recorder.get(), "pending items are delivered on close, discarded on stop")
+	assert.Equal(t, batch.ErrBatcherClosed, b.Add("d"))
+}
+
+func TestBatcherConcurrentAdd(t *testing.T) {
+	var delivered atomic.Int64
+	var concurrent atomic.Int32
+	b := batch.New(func(items []int) {
+		assert.Equal(t, int32(1), concurrent.Add(1), "handler must not be called concurrently")
+		assert.LessOrEqual(t, len(items), 7)
+		delivered.Add(int64(len(items)))
+		concurrent.Add(-1)
+	}, batch.WithMaxItems[int](7), batch.WithTimeout[int](time.Millisecond))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				assert.NoError(t, b.Add(i))
+			}
+		}()
+	}
+	wg.Wait()
+	b.Close()
+	assert.Equal(t, int64(800), delivered.Load())
+}
+
+// bufferedProcessor keeps a batcher of the messages it receives
+type bufferedProcessor struct {
+	batcher *batch.Batcher[string]
+}
+
+func (p *bufferedProcessor) Process(msg actor.Message) {
+	if body, ok := msg.Body.(string); ok {
+		p.batcher.Add(body)
+	}
+}
+
+func (p *bufferedProcessor) Shutdown() {}
+
+func (p *bufferedProcessor) GetState() any {
+	return nil
+}
+
+func TestBatcherFlushedOnActorDrop(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	recorder := &batchRecorder[string]{}
+	p := &bufferedProcessor{batcher: batch.New(recorder.handle, batch.WithMaxItems[string](10))}
+	a, err := s.RegisterActor(actor.NewAddress("local", "buffered"), p)
+	assert.NoError(t, err)
+	p.batcher.Bind(a)
+
+	for _, body := range []string{"x", "y"} {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, body)))
+	}
+	assert.Eventually(t, func() bool {
+		return p.batcher.Len() == 2
+	}, time.Second, 5*time.Millisecond)
+
+	a.Drop()
+	assert.Equal(t, [][]string{{"x", "y"}}, recorder.get())
+	assert.Equal(t, batch.ErrBatcherClosed, p.batcher.Add("z"))
+}
+
+// ownedBatchProcessor batches the bodies it receives and handles the batches in Process, without locks
+type ownedBatchProcessor struct {
+	clock   actor.Clock
+	batcher *batch.Batcher[string]
+	handled [][]string
+	ready   chan struct{}
+}
+
+func (p *ownedBatchProcessor) PreStart(ctx context.Context) error {
+	a, _ := actor.ActorFromContext(ctx)
+	p.batcher = batch.NewForActor[string](a, batch.WithMaxItems[string](3), batch.WithTimeout[string](time.Second), batch.WithClock[string](p.clock))
+	return nil
+}
+
+func (p *ownedBatchProcessor) Process(msg actor.Message) {
+	switch body := msg.Body.(type) {
+	case string:
+		p.batcher.Add(body)
+	case batch.BatchReady[string]:
+		p.handled = append(p.handled, body.Items)
+		p.ready <- struct{}{}
+	}
+}
+
+func (p *ownedBatchProcessor) Shutdown() {}
+
+func (p *ownedBatchProcessor) GetState() any {
+	return append([][]string{}, p.handled...)
+}
+
+func receiveBatch(t *testing.T, ready <-chan struct{}) {
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+		t.Fatal("batch not handled in time")
+	}
+}
+
+func TestBatcherPostsBatchesToOwningActor(t *testing.T) {
+	clock := actor.NewManualClock(batchStart)
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	p := &ownedBatchProcessor{clock: clock, ready: make(chan struct{}, 10)}
+	a, err := s.RegisterActor(actor.NewAddress("local", "owned"), p)
+	assert.NoError(t, err)
+
+	for _, body := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, body)))
+	}
+	receiveBatch(t, p.ready)
+	assert.Eventually(t, func() bool {
+		return clock.Pending() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	clock.Advance(time.Second)
+	receiveBatch(t, p.ready)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d"}}, a.GetState(), "full and expired batches are handled by the actor")
+}
+
+func TestBatcherOfDroppedActorReportsDeadLetter(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	p := &ownedBatchProcessor{clock: actor.RealClock, ready: make(chan struct{}, 10)}
+	a, err := s.RegisterActor(actor.NewAddress("local", "owned"), p)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "a")))
+	assert.Eventually(t, func() bool {
+		return a.MailboxMetrics().Depth == 0 && p.batcher.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+	a.Drop()
+
+	assert.Eventually(t, func() bool {
+		letters := s.DeadLetters().Letters()
+		return len(letters) == 1 && assert.ObjectsAreEqual(batch.BatchReady[string]{Items: []string{"a"}}, letters[0].Message.Body)
+	}, time.Second, 5*time.Millisecond, "pending batch of a dropped actor is a dead letter")
+}
+
+// gatedBatchProcessor batches one body per batch and waits for release at the first message
+type gatedBatchProcessor struct {
+	batcher *batch.Batcher[int]
+	started chan struct{}
+	release chan struct{}
+	handled atomic.Int64
+}
+
+func (p *gatedBatchProcessor) PreStart(ctx context.Context) error {
+	a, _ := actor.ActorFromContext(ctx)
+	p.batcher = batch.NewForActor[int](a, batch.WithMaxItems[int](1))
+	return nil
+}
+
+func (p *gatedBatchProcessor) Process(msg actor.Message) {
+	switch body := msg.Body.(type) {
+	case int:
+		if body == 0 {
+			p.started <- struct{}{}
+			<-p.release
+		}
+		p.batcher.Add(body)
+	case batch.BatchReady[int]:
+		p.handled.Add(int64(len(body.Items)))
+	}
+}
+
+func (p *gatedBatchProcessor) Shutdown() {}
+
+func (p *gatedBatchProcessor) GetState() any {
+	return nil
+}
+
+func TestBatcherPostsToFullMailboxOfOwningActor(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+
+	p := &gatedBatchProcessor{started: make(chan struct{}), release: make(chan struct{})}
+	a, err := s.RegisterActor(actor.NewAddress("local", "gated"), p)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, 0)))
+	<-p.started
+	for i := 1; i <= actor.DefaultMailboxCapacity; i++ {
+		assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, i)))
+	}
+	assert.Equal(t, actor.DefaultMailboxCapacity, a.MailboxMetrics().Depth, "default mailbox is full")
+
+	close(p.release)
+	assert.Eventually(t, func() bool {
+		return p.handled.Load() == int64(actor.DefaultMailboxCapacity+1)
+	}, time.Second, 5*time.Millisecond, "batches completed in Process are posted to the full mailbox without blocking")
+}
+
+func TestBatcherDebounce(t *testing.T) {
+	clock := actor.NewManualClock(batchStart)
+	recorder := &batchRecorder[int]{}
+	b := batch.New(recorder.handle, batch.WithDebounce[int](100*time.Millisecond), batch.WithTimeout[int](250*time.Millisecond), batch.WithClock[int](clock))
+
+	assert.NoError(t, b.Add(1))
+	clock.Advance(80 * time.Millisecond)
+	assert.NoError(t, b.Add(2))
+	clock.Advance(80 * time.Millisecond)
+	assert.Empty(t, recorder.get(), "debounce timer restarts on every add")
+	clock.Advance(20 * time.Millisecond)
+	assert.Equal(t, [][]int{{1, 2}}, recorder.get())
+
+	for i := 3; i <= 6; i++ {
+		assert.NoError(t, b.Add(i))
+		clock.Advance(80 * time.Millisecond)
+	}
+	assert.Equal(t, [][]int{{1, 2}, {3, 4, 5, 6}}, recorder.get(), "timeout is the max wait of a debounced batch")
+	assert.Equal(t, 0, clock.Pending())
+}
+
+func TestBatcherThrottle(t *testing.T) {
+	clock := actor.NewManualClock(batchStart)
+	recorder := &batchRecorder[int]{}
+	b := batch.New(recorder.handle, batch.WithMaxItems[int](2), batch.WithThrottle[int](time.Second), batch.WithClock[int](clock))
+
+	for i := range 7 {
+		assert.NoError(t, b.Add(i))
+	}
+	assert.Equal(t, [][]int{{0, 1}}, recorder.get(), "one batch per interval")
+	assert.Equal(t, 5, b.Len())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}}, recorder.get())
+	clock.Advance(500 * time.Millisecond)
+	assert.Len(t, recorder.get(), 2)
+
+	b.Flush()
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4, 5}, {6}}, recorder.get(), "flush ignores the throttle")
+	assert.Equal(t, 0, clock.Pending())
+
+	assert.NoError(t, b.Add(7))
+	assert.NoError(t, b.Add(8))
+	assert.Len(t, recorder.get(), 4, "flush counts as delivery for the throttle")
+	clock.Advance(time.Second)
+	assert.Equal(t, []int{7, 8}, recorder.get()[4])
+}
+
+func TestBatcherMaxBytes(t *testing.T) {
+	recorder := &batchRecorder[string]{}
+	b := batch.New(recorder.handle, batch.WithMaxBytes(10, func(s string) int {
+		return len(s)
+	}))
+
+	for _, item := range []string{"abcd", "efgh", "ijk", "lmnopqrstuvwxyz", "12345", "67890"} {
+		assert.NoError(t, b.Add(item))
+	}
+	assert.Equal(t, [][]string{{"abcd", "efgh"}, {"ijk"}, {"lmnopqrstuvwxyz"}, {"12345", "67890"}}, recorder.get())
+	assert.Equal(t, 0, b.Len())
+}
+
+type priceUpdate struct {
+	Code  string
+	Price int
+}
+
+func TestBatcherKeepsLastPerKey(t *testing.T) {
+	recorder := &batchRecorder[priceUpdate]{}
+	b := batch.New(recorder.handle, batch.WithMaxItems[priceUpdate](3), batch.WithKey(func(u priceUpdate) string {
+		return u.Code
+	}))
+
+	for _, u := range []priceUpdate{{"A", 1}, {"B", 1}, {"A", 2}, {"A", 3}, {"C", 1}, {"A", 4}} {
+		assert.NoError(t, b.Add(u))
+	}
+	assert.Equal(t, [][]priceUpdate{{{"A", 3}, {"B", 1}, {"C", 1}}}, recorder.get(), "max items counts the distinct keys")
+	assert.Equal(t, 1, b.Len())
+
+	b.Flush()
+	assert.Equal(t, []priceUpdate{{"A", 4}}, recorder.get()[1], "keys are reset with the batch")
+}
+
+func TestBatcherOfActorFlushedOnGracefulShutdown(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+
+	p := &ownedBatchProcessor{clock: actor.RealClock, ready: make(chan struct{}, 10)}
+	a, err := s.RegisterActor(actor.NewAddress("local", "owned"), p)
+	assert.NoError(t, err)
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "a")))
+	assert.NoError(t, s.SendMessage(actor.NewMessage(a.GetAddress(), nil, "b")))
+
+	report, err := s.GracefulShutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.DroppedMessages())
+	assert.Equal(t, [][]string{{"a", "b"}}, p.handled, "pending items are handled by the actor before it stops")
+	assert.Empty(t, s.DeadLetters().Letters())
+}
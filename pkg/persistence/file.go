@@ -0,0 +1,249 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	fileEventsExtension   = ".log"
+	fileSnapshotExtension = ".snapshot"
+)
+
+// FileJournal stores the events of every persistence id in an append-only log file with one JSON event per line,
+// and its latest snapshot in a file replaced atomically, in a directory
+type FileJournal struct {
+	dir    string
+	sync   bool
+	mutex  sync.Mutex
+	logs   map[string]fileLog
+	closed bool
+}
+
+// fileLog is the last sequence and the size of the valid content of a log file
+type fileLog struct {
+	last uint64
+	size int64
+}
+
+type FileJournalOption func(*FileJournal)
+
+// WithFileSync makes the journal sync the log file to disk on every append, to survive a crash of the machine and not only of the process
+func WithFileSync() FileJournalOption {
+	return func(j *FileJournal) {
+		j.sync = true
+	}
+}
+
+// NewFileJournal creates a journal in dir, creating the directory if needed
+func NewFileJournal(dir string, opts ...FileJournalOption) (*FileJournal, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &FileJournal{
+		dir:  dir,
+		logs: make(map[string]fileLog),
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j, nil
+}
+
+func (j *FileJournal) path(persistenceID, extension string) string {
+	return filepath.Join(j.dir, url.PathEscape(persistenceID)+extension)
+}
+
+func (j *FileJournal) Append(ctx context.Context, persistenceID string, events []Event) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	log, err := j.log(persistenceID)
+	if err != nil {
+		return err
+	}
+	err = checkSequence(log.last, events)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range events {
+		err = encoder.Encode(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(j.path(persistenceID, fileEventsExtension), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// an incomplete event left by a failed append is overwritten
+	err = f.Truncate(log.size)
+	if err == nil {
+		_, err = f.WriteAt(buf.Bytes(), log.size)
+	}
+	if err == nil && j.sync {
+		err = f.Sync()
+	}
+	if err != nil {
+		delete(j.logs, persistenceID)
+		return err
+	}
+	j.logs[persistenceID] = fileLog{last: log.last + uint64(len(events)), size: log.size + int64(buf.Len())}
+	return nil
+}
+
+func (j *FileJournal) Replay(ctx context.Context, persistenceID string, from uint64, f func(Event) error) error {
+	j.mutex.Lock()
+	closed := j.closed
+	j.mutex.Unlock()
+	if closed {
+		return ErrJournalClosed
+	}
+
+	_, err := j.scan(persistenceID, func(e Event) error {
+		if e.Sequence < from {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return f(e)
+	})
+	return err
+}
+
+// scan reads the events of the log file in order and returns the size of the complete ones:
+// an incomplete last line, left by a crash while appending, is ignored
+func (j *FileJournal) scan(persistenceID string, f func(Event) error) (int64, error) {
+	file, err := os.Open(j.path(persistenceID, fileEventsExtension))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var size int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				slog.Warn("journal incomplete event ignored", slog.String("persistence-id", persistenceID))
+			}
+			return size, nil
+		}
+		if err != nil {
+			return size, err
+		}
+
+		var e Event
+		err = json.Unmarshal(line, &e)
+		if err != nil {
+			return size, err
+		}
+		err = f(e)
+		if err != nil {
+			return size, err
+		}
+		size += int64(len(line))
+	}
+}
+
+func (j *FileJournal) LastSequence(ctx context.Context, persistenceID string) (uint64, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return 0, ErrJournalClosed
+	}
+	log, err := j.log(persistenceID)
+	return log.last, err
+}
+
+// log returns the cached state of the log file, scanning it the first time; the caller must hold the lock
+func (j *FileJournal) log(persistenceID string) (fileLog, error) {
+	if log, ok := j.logs[persistenceID]; ok {
+		return log, nil
+	}
+	var log fileLog
+	size, err := j.scan(persistenceID, func(e Event) error {
+		log.last = e.Sequence
+		return nil
+	})
+	if err != nil {
+		return fileLog{}, err
+	}
+	log.size = size
+	j.logs[persistenceID] = log
+	return log, nil
+}
+
+func (j *FileJournal) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	path := j.path(snapshot.PersistenceID, fileSnapshotExtension)
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (j *FileJournal) LoadSnapshot(ctx context.Context, persistenceID string) (Snapshot, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return Snapshot{}, ErrJournalClosed
+	}
+	data, err := os.ReadFile(j.path(persistenceID, fileSnapshotExtension))
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+func (j *FileJournal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.closed = true
+	return nil
+}
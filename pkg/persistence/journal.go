@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSequenceConflict  = errors.New("event sequence does not follow the last stored one")
+	ErrSnapshotNotFound  = errors.New("snapshot not found")
+	ErrJournalClosed     = errors.New("journal is closed")
+	ErrPersistenceIDVoid = errors.New("persistence id must be not empty")
+)
+
+// Event is an event of a persistent actor as stored in a journal
+type Event struct {
+	PersistenceID string    `json:"persistenceId"`
+	Sequence      uint64    `json:"sequence"`
+	Type          string    `json:"type"`
+	Data          []byte    `json:"data"`
+	At            time.Time `json:"at"`
+}
+
+// Snapshot is the serialized state of a persistent actor after the event with the given sequence
+type Snapshot struct {
+	PersistenceID string    `json:"persistenceId"`
+	Sequence      uint64    `json:"sequence"`
+	Data          []byte    `json:"data"`
+	At            time.Time `json:"at"`
+}
+
+// Journal stores the events and the latest snapshot of persistent actors, identified by persistence id
+type Journal interface {
+	// Append stores the events atomically: their sequences must follow the last stored one, otherwise it fails with ErrSequenceConflict
+	Append(ctx context.Context, persistenceID string, events []Event) error
+	// Replay calls f for every event with sequence greater or equal to from, in order; it stops at the first error of f
+	Replay(ctx context.Context, persistenceID string, from uint64, f func(Event) error) error
+	// LastSequence returns the sequence of the last event stored, 0 if none
+	LastSequence(ctx context.Context, persistenceID string) (uint64, error)
+	// SaveSnapshot replaces the snapshot of the persistence id
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	// LoadSnapshot returns the latest snapshot, ErrSnapshotNotFound if none
+	LoadSnapshot(ctx context.Context, persistenceID string) (Snapshot, error)
+	Close() error
+}
+
+// checkSequence verifies that the events are numbered from last+1 without gaps
+func checkSequence(last uint64, events []Event) error {
+	for i, e := range events {
+		if e.Sequence != last+uint64(i)+1 {
+			return ErrSequenceConflict
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEvents(id string, from uint64, types ...string) []persistence.Event {
+	events := make([]persistence.Event, 0, len(types))
+	for i, t := range types {
+		events = append(events, persistence.Event{
+			PersistenceID: id,
+			Sequence:      from + uint64(i),
+			Type:          t,
+			Data:          []byte(`{}`),
+			At:            time.Now(),
+		})
+	}
+	return events
+}
+
+func replayTypes(t *testing.T, j persistence.Journal, id string, from uint64) []string {
+	types := make([]string, 0)
+	err := j.Replay(context.Background(), id, from, func(e persistence.Event) error {
+		types = append(types, e.Type)
+		return nil
+	})
+	assert.NoError(t, err)
+	return types
+}
+
+// testJournal checks the contract of a journal
+func testJournal(t *testing.T, j persistence.Journal) {
+	ctx := context.Background()
+
+	last, err := j.LastSequence(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), last)
+	assert.Empty(t, replayTypes(t, j, "products", 1))
+
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 1, "added", "added")))
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 3, "removed")))
+	assert.NoError(t, j.Append(ctx, "orders/order-1", newEvents("orders/order-1", 1, "created")))
+
+	err = j.Append(ctx, "products", newEvents("products", 3, "removed"))
+	assert.ErrorIs(t, err, persistence.ErrSequenceConflict, "a sequence already stored must be rejected")
+	err = j.Append(ctx, "products", newEvents("products", 5, "removed"))
+	assert.ErrorIs(t, err, persistence.ErrSequenceConflict, "a gap in the sequence must be rejected")
+
+	last, err = j.LastSequence(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), last)
+	assert.Equal(t, []string{"added", "added", "removed"}, replayTypes(t, j, "products", 1))
+	assert.Equal(t, []string{"removed"}, replayTypes(t, j, "products", 3))
+	assert.Equal(t, []string{"created"}, replayTypes(t, j, "orders/order-1", 0))
+
+	stop := errors.New("stop")
+	calls := 0
+	err = j.Replay(ctx, "products", 1, func(e persistence.Event) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+
+	_, err = j.LoadSnapshot(ctx, "products")
+	assert.ErrorIs(t, err, persistence.ErrSnapshotNotFound)
+	assert.NoError(t, j.SaveSnapshot(ctx, persistence.Snapshot{PersistenceID: "products", Sequence: 2, Data: []byte("two"), At: time.Now()}))
+	assert.NoError(t, j.SaveSnapshot(ctx, persistence.Snapshot{PersistenceID: "products", Sequence: 3, Data: []byte("three"), At: time.Now()}))
+	snapshot, err := j.LoadSnapshot(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), snapshot.Sequence)
+	assert.Equal(t, []byte("three"), snapshot.Data, "the latest snapshot replaces the previous one")
+}
+
+func TestMemoryJournal(t *testing.T) {
+	j := persistence.NewMemoryJournal()
+	testJournal(t, j)
+
+	assert.NoError(t, j.Close())
+	assert.ErrorIs(t, j.Append(context.Background(), "products", newEvents("products", 4, "added")), persistence.ErrJournalClosed)
+}
+
+func TestFileJournal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := persistence.NewFileJournal(filepath.Join(dir, "journal"), persistence.WithFileSync())
+	assert.NoError(t, err)
+	testJournal(t, j)
+	assert.NoError(t, j.Close())
+
+	reopened, err := persistence.NewFileJournal(filepath.Join(dir, "journal"))
+	assert.NoError(t, err)
+	last, err := reopened.LastSequence(context.Background(), "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), last, "events survive the process")
+	snapshot, err := reopened.LoadSnapshot(context.Background(), "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), snapshot.Sequence)
+}
+
+func TestFileJournalIgnoresIncompleteEvent(t *testing.T) {
+	dir := t.TempDir()
+	j, err := persistence.NewFileJournal(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, j.Append(context.Background(), "products", newEvents("products", 1, "added")))
+
+	// simulate a crash while appending the second event
+	f, err := os.OpenFile(filepath.Join(dir, "products.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"persistenceId":"products","sequ`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	reopened, err := persistence.NewFileJournal(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"added"}, replayTypes(t, reopened, "products", 1))
+	assert.NoError(t, reopened.Append(context.Background(), "products", newEvents("products", 2, "removed")))
+	assert.Equal(t, []string{"added", "removed"}, replayTypes(t, reopened, "products", 1), "the incomplete event is overwritten")
+}
+
+func TestSQLJournal(t *testing.T) {
+	db, fake := openFakeSQL(t.Name())
+	defer db.Close()
+
+	j, err := persistence.NewSQLJournal(context.Background(), db, persistence.WithSQLTablePrefix("app_"))
+	assert.NoError(t, err)
+	testJournal(t, j)
+	assert.Contains(t, fake.queries, "SELECT MAX(sequence) FROM app_events WHERE persistence_id = ?")
+
+	postgres, err := persistence.NewSQLJournal(context.Background(), db, persistence.WithSQLPostgreSQL())
+	assert.NoError(t, err)
+	_, err = postgres.LoadSnapshot(context.Background(), "products")
+	assert.NoError(t, err)
+	assert.Contains(t, fake.queries, "SELECT sequence, data, created_at FROM cinecity_snapshots WHERE persistence_id = $1")
+	for _, query := range fake.queries {
+		if strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS cinecity_") {
+			assert.Contains(t, query, "data BYTEA,")
+		}
+	}
+}
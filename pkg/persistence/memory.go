@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryJournal keeps events and snapshots in memory: the state survives the restart of an actor, not of the process
+type MemoryJournal struct {
+	mutex     sync.RWMutex
+	events    map[string][]Event
+	snapshots map[string]Snapshot
+	closed    bool
+}
+
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{
+		events:    make(map[string][]Event),
+		snapshots: make(map[string]Snapshot),
+	}
+}
+
+func (j *MemoryJournal) Append(ctx context.Context, persistenceID string, events []Event) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	stored := j.events[persistenceID]
+	err := checkSequence(uint64(len(stored)), events)
+	if err != nil {
+		return err
+	}
+	j.events[persistenceID] = append(stored, events...)
+	return nil
+}
+
+func (j *MemoryJournal) Replay(ctx context.Context, persistenceID string, from uint64, f func(Event) error) error {
+	j.mutex.RLock()
+	if j.closed {
+		j.mutex.RUnlock()
+		return ErrJournalClosed
+	}
+	stored := j.events[persistenceID]
+	j.mutex.RUnlock()
+
+	for _, e := range stored {
+		if e.Sequence < from {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *MemoryJournal) LastSequence(ctx context.Context, persistenceID string) (uint64, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if j.closed {
+		return 0, ErrJournalClosed
+	}
+	return uint64(len(j.events[persistenceID])), nil
+}
+
+func (j *MemoryJournal) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	j.snapshots[snapshot.PersistenceID] = snapshot
+	return nil
+}
+
+func (j *MemoryJournal) LoadSnapshot(ctx context.Context, persistenceID string) (Snapshot, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if j.closed {
+		return Snapshot{}, ErrJournalClosed
+	}
+	snapshot, ok := j.snapshots[persistenceID]
+	if !ok {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (j *MemoryJournal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.closed = true
+	return nil
+}
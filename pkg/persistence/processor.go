@@ -0,0 +1,210 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/actor"
+)
+
+var (
+	ErrEventTypeNotRegistered = errors.New("event type not found in registry")
+	ErrEventReplayFailed      = errors.New("events replay failed")
+)
+
+// EventSourced is the state of a persistent actor, rebuilt by applying its events
+type EventSourced interface {
+	// PersistenceID identifies the events of the state in the journal, it must be stable across restarts
+	PersistenceID() string
+	// HandleCommand validates a message and returns the events to persist and the body to reply if the message waits for a response.
+	// It must not change the state: an error is replied without persisting anything.
+	HandleCommand(ctx context.Context, msg actor.Message) (events []any, reply any, err error)
+	// ApplyEvent changes the state, for events just persisted and for events replayed on activation
+	ApplyEvent(event any)
+}
+
+// Snapshotter is implemented by states that can be serialized, so they are restored from the latest snapshot
+// before replaying only the following events; it is the same interface of the actor snapshots
+type Snapshotter = actor.Snapshotter
+
+// PersistentProcessor is the state processor of an event sourced actor: events returned by the commands are appended to the journal
+// and then applied to the state, on activation the state is restored from the latest snapshot and the journal.
+type PersistentProcessor struct {
+	state         EventSourced
+	journal       Journal
+	eventTypes    actor.EnvelopePayloadTypeRegistry
+	snapshotEvery uint64
+	sequence      uint64
+	snapshotSeq   uint64
+	now           func() time.Time
+}
+
+type PersistentProcessorOption func(*PersistentProcessor)
+
+// WithSnapshotEvery saves a snapshot every n events, if the state is a Snapshotter
+func WithSnapshotEvery(n uint64) PersistentProcessorOption {
+	return func(p *PersistentProcessor) {
+		p.snapshotEvery = n
+	}
+}
+
+// NewPersistentProcessor creates the state processor of a persistent actor; eventTypes maps the event type names,
+// as reported by reflect (es. "main.ProductAdded"), to the types used to decode them on replay
+func NewPersistentProcessor(state EventSourced, journal Journal, eventTypes actor.EnvelopePayloadTypeRegistry, opts ...PersistentProcessorOption) *PersistentProcessor {
+	p := &PersistentProcessor{
+		state:      state,
+		journal:    journal,
+		eventTypes: eventTypes,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PreStart restores the state from the journal, an error prevents the activation of the actor
+func (p *PersistentProcessor) PreStart(ctx context.Context) error {
+	id := p.state.PersistenceID()
+	if id == "" {
+		return ErrPersistenceIDVoid
+	}
+
+	if s, ok := p.state.(Snapshotter); ok {
+		snapshot, err := p.journal.LoadSnapshot(ctx, id)
+		switch {
+		case err == nil:
+			err = s.Restore(snapshot.Data)
+			if err != nil {
+				return fmt.Errorf("%w: snapshot %d: %w", ErrEventReplayFailed, snapshot.Sequence, err)
+			}
+			p.sequence = snapshot.Sequence
+			p.snapshotSeq = snapshot.Sequence
+		case !errors.Is(err, ErrSnapshotNotFound):
+			return fmt.Errorf("%w: %w", ErrEventReplayFailed, err)
+		}
+	}
+
+	replayed := 0
+	err := p.journal.Replay(ctx, id, p.sequence+1, func(e Event) error {
+		event, err := p.eventTypes.Decode(e.Type, e.Data)
+		if err != nil {
+			return fmt.Errorf("event %d: %w", e.Sequence, err)
+		}
+		p.state.ApplyEvent(event)
+		p.sequence = e.Sequence
+		replayed++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEventReplayFailed, err)
+	}
+
+	slog.Info("persistent actor recovered", slog.String("persistence-id", id), slog.Uint64("sequence", p.sequence), slog.Int("replayed", replayed))
+	return nil
+}
+
+func (p *PersistentProcessor) Process(msg actor.Message) {
+	p.ProcessContext(msg.Context(), msg)
+}
+
+func (p *PersistentProcessor) ProcessContext(ctx context.Context, msg actor.Message) {
+	events, reply, err := p.state.HandleCommand(ctx, msg)
+	if err == nil && len(events) > 0 {
+		err = p.persist(ctx, events)
+	}
+	if msg.WithResponse && msg.ResponseChan != nil {
+		if err != nil {
+			reply = nil
+		}
+		msg.ResponseChan <- actor.NewReturnMessage(reply, msg, err)
+	}
+	if err != nil {
+		slog.Warn("persistent actor command failed", slog.String("persistence-id", p.state.PersistenceID()), slog.String("err", err.Error()))
+	}
+}
+
+// persist appends the events and applies them to the state only if they are stored
+func (p *PersistentProcessor) persist(ctx context.Context, events []any) error {
+	id := p.state.PersistenceID()
+	now := p.now()
+	stored := make([]Event, 0, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		eventType := reflect.TypeOf(event).String()
+		if _, ok := p.eventTypes[eventType]; !ok {
+			return fmt.Errorf("%w: %s", ErrEventTypeNotRegistered, eventType)
+		}
+		stored = append(stored, Event{
+			PersistenceID: id,
+			Sequence:      p.sequence + uint64(i) + 1,
+			Type:          eventType,
+			Data:          data,
+			At:            now,
+		})
+	}
+
+	err := p.journal.Append(ctx, id, stored)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		p.state.ApplyEvent(event)
+	}
+	p.sequence += uint64(len(events))
+
+	if p.snapshotEvery > 0 && p.sequence-p.snapshotSeq >= p.snapshotEvery {
+		p.saveSnapshot(ctx)
+	}
+	return nil
+}
+
+// saveSnapshot stores the state after the last event; a failure is logged, the events are still in the journal
+func (p *PersistentProcessor) saveSnapshot(ctx context.Context) {
+	s, ok := p.state.(Snapshotter)
+	if !ok {
+		return
+	}
+	data, err := s.Snapshot()
+	if err == nil {
+		err = p.journal.SaveSnapshot(ctx, Snapshot{
+			PersistenceID: p.state.PersistenceID(),
+			Sequence:      p.sequence,
+			Data:          data,
+			At:            p.now(),
+		})
+	}
+	if err != nil {
+		slog.Warn("persistent actor snapshot failed", slog.String("persistence-id", p.state.PersistenceID()), slog.String("err", err.Error()))
+		return
+	}
+	p.snapshotSeq = p.sequence
+}
+
+// Sequence returns the sequence of the last event applied to the state
+func (p *PersistentProcessor) Sequence() uint64 {
+	return p.sequence
+}
+
+// Shutdown calls Shutdown on the state if it implements it; the journal is not closed since it can be shared by more actors
+func (p *PersistentProcessor) Shutdown() {
+	if s, ok := p.state.(interface{ Shutdown() }); ok {
+		s.Shutdown()
+	}
+}
+
+// GetState returns the result of GetState of the state, if implemented, or the state itself
+func (p *PersistentProcessor) GetState() any {
+	if s, ok := p.state.(interface{ GetState() any }); ok {
+		return s.GetState()
+	}
+	return p.state
+}
@@ -0,0 +1,153 @@
+package persistence_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pix303/cinecity/pkg/actor"
+	"github.com/pix303/cinecity/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+type AddStock struct {
+	Code     string
+	Quantity int
+}
+
+type GetStock struct {
+	Code string
+}
+
+type StockAdded struct {
+	Code     string
+	Quantity int
+}
+
+var errQuantityInvalid = errors.New("quantity must be positive")
+
+var stockEvents = actor.EnvelopePayloadTypeRegistry{
+	reflect.TypeOf(StockAdded{}).String(): reflect.TypeOf(StockAdded{}),
+}
+
+// inventory is an event sourced state counting the stock of products
+type inventory struct {
+	Stock   map[string]int
+	applied int
+}
+
+func newInventory() *inventory {
+	return &inventory{Stock: make(map[string]int)}
+}
+
+func (s *inventory) PersistenceID() string {
+	return "inventory"
+}
+
+func (s *inventory) HandleCommand(ctx context.Context, msg actor.Message) ([]any, any, error) {
+	switch body := msg.Body.(type) {
+	case AddStock:
+		if body.Quantity <= 0 {
+			return nil, nil, errQuantityInvalid
+		}
+		return []any{StockAdded(body)}, s.Stock[body.Code] + body.Quantity, nil
+	case GetStock:
+		return nil, s.Stock[body.Code], nil
+	}
+	return nil, nil, nil
+}
+
+func (s *inventory) ApplyEvent(event any) {
+	if e, ok := event.(StockAdded); ok {
+		s.Stock[e.Code] += e.Quantity
+		s.applied++
+	}
+}
+
+func (s *inventory) Snapshot() ([]byte, error) {
+	return json.Marshal(s.Stock)
+}
+
+func (s *inventory) Restore(data []byte) error {
+	return json.Unmarshal(data, &s.Stock)
+}
+
+var inventoryAddress = actor.NewAddress("local", "inventory")
+
+func addStock(t *testing.T, s *actor.ActorSystem, code string, quantity int) (int, error) {
+	return actor.AskAs[int](s, actor.NewMessageWithResponse(inventoryAddress, nil, AddStock{Code: code, Quantity: quantity}))
+}
+
+func TestPersistentActorRecoversState(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+	journal := persistence.NewMemoryJournal()
+
+	state := newInventory()
+	a, err := s.RegisterActor(inventoryAddress, persistence.NewPersistentProcessor(state, journal, stockEvents))
+	assert.NoError(t, err)
+
+	stock, err := addStock(t, s, "A", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stock)
+	stock, err = addStock(t, s, "A", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stock)
+	_, err = addStock(t, s, "A", -1)
+	assert.Equal(t, errQuantityInvalid, err, "a rejected command is not persisted")
+	a.Drop()
+
+	recovered := newInventory()
+	p := persistence.NewPersistentProcessor(recovered, journal, stockEvents)
+	_, err = s.RegisterActor(inventoryAddress, p)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), p.Sequence())
+
+	stock, err = actor.AskAs[int](s, actor.NewMessageWithResponse(inventoryAddress, nil, GetStock{Code: "A"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stock, "state must be rebuilt from the events")
+}
+
+func TestPersistentActorSnapshots(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+	journal := persistence.NewMemoryJournal()
+
+	a, err := s.RegisterActor(inventoryAddress, persistence.NewPersistentProcessor(newInventory(), journal, stockEvents, persistence.WithSnapshotEvery(2)))
+	assert.NoError(t, err)
+	for range 5 {
+		_, err = addStock(t, s, "B", 1)
+		assert.NoError(t, err)
+	}
+	a.Drop()
+
+	snapshot, err := journal.LoadSnapshot(context.Background(), "inventory")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), snapshot.Sequence)
+
+	recovered := newInventory()
+	_, err = s.RegisterActor(inventoryAddress, persistence.NewPersistentProcessor(recovered, journal, stockEvents))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, recovered.Stock["B"])
+	assert.Equal(t, 1, recovered.applied, "only the events after the snapshot are replayed")
+}
+
+func TestPersistentActorFailures(t *testing.T) {
+	s, _ := actor.NewActorSystem()
+	defer s.Shutdown()
+	journal := persistence.NewMemoryJournal()
+
+	_, err := s.RegisterActor(inventoryAddress, persistence.NewPersistentProcessor(newInventory(), journal, actor.EnvelopePayloadTypeRegistry{}))
+	assert.NoError(t, err)
+	_, err = addStock(t, s, "C", 1)
+	assert.ErrorIs(t, err, persistence.ErrEventTypeNotRegistered)
+
+	assert.NoError(t, journal.Append(context.Background(), "inventory", []persistence.Event{
+		{PersistenceID: "inventory", Sequence: 1, Type: "persistence_test.Unknown", Data: []byte(`{}`)},
+	}))
+	_, err = s.RegisterActor(actor.NewAddress("local", "broken"), persistence.NewPersistentProcessor(newInventory(), journal, stockEvents))
+	assert.ErrorIs(t, err, actor.ErrActorStartFailed)
+	assert.ErrorIs(t, err, persistence.ErrEventReplayFailed, "an event that can't be decoded prevents the activation")
+}
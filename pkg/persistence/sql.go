@@ -0,0 +1,235 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const DefaultSQLTablePrefix = "cinecity_"
+
+// SQLJournal stores events and snapshots in two tables of a database/sql database, like an embedded SQLite file.
+// The database and its driver are chosen by the caller; the statements use portable SQL, "?" placeholders and BLOB columns by default.
+type SQLJournal struct {
+	db             *sql.DB
+	eventsTable    string
+	snapshotsTable string
+	binaryType     string
+	placeholder    func(n int) string
+}
+
+type SQLJournalOption func(*SQLJournal)
+
+// WithSQLTablePrefix sets the prefix of the events and snapshots tables, DefaultSQLTablePrefix by default
+func WithSQLTablePrefix(prefix string) SQLJournalOption {
+	return func(j *SQLJournal) {
+		j.eventsTable = prefix + "events"
+		j.snapshotsTable = prefix + "snapshots"
+	}
+}
+
+// WithSQLNumberedPlaceholders makes the statements use "$1", "$2"... placeholders, as PostgreSQL drivers require
+func WithSQLNumberedPlaceholders() SQLJournalOption {
+	return func(j *SQLJournal) {
+		j.placeholder = func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		}
+	}
+}
+
+// WithSQLBinaryType sets the column type of the event and snapshot data in the created tables, BLOB by default
+// (e.g. BYTEA for PostgreSQL, VARBINARY(MAX) for SQL Server)
+func WithSQLBinaryType(columnType string) SQLJournalOption {
+	return func(j *SQLJournal) {
+		j.binaryType = columnType
+	}
+}
+
+// WithSQLPostgreSQL sets the placeholders and the column types required by PostgreSQL
+func WithSQLPostgreSQL() SQLJournalOption {
+	return func(j *SQLJournal) {
+		WithSQLNumberedPlaceholders()(j)
+		WithSQLBinaryType("BYTEA")(j)
+	}
+}
+
+// NewSQLJournal creates a journal over db and creates its tables if they don't exist; db is not closed by Close
+func NewSQLJournal(ctx context.Context, db *sql.DB, opts ...SQLJournalOption) (*SQLJournal, error) {
+	j := &SQLJournal{
+		db:         db,
+		binaryType: "BLOB",
+		placeholder: func(n int) string {
+			return "?"
+		},
+	}
+	WithSQLTablePrefix(DefaultSQLTablePrefix)(j)
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	persistence_id VARCHAR(255) NOT NULL,
+	sequence BIGINT NOT NULL,
+	event_type VARCHAR(255) NOT NULL,
+	data %s,
+	created_at BIGINT NOT NULL,
+	PRIMARY KEY (persistence_id, sequence)
+)`, j.eventsTable, j.binaryType),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	persistence_id VARCHAR(255) NOT NULL PRIMARY KEY,
+	sequence BIGINT NOT NULL,
+	data %s,
+	created_at BIGINT NOT NULL
+)`, j.snapshotsTable, j.binaryType),
+	}
+	for _, stmt := range statements {
+		_, err := db.ExecContext(ctx, stmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// query replaces the "?" placeholders of the statement with the ones of the driver
+func (j *SQLJournal) query(stmt string) string {
+	parts := strings.Split(stmt, "?")
+	var b strings.Builder
+	for i, part := range parts {
+		b.WriteString(part)
+		if i < len(parts)-1 {
+			b.WriteString(j.placeholder(i + 1))
+		}
+	}
+	return b.String()
+}
+
+func (j *SQLJournal) Append(ctx context.Context, persistenceID string, events []Event) error {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	last, err := j.lastSequence(ctx, tx, persistenceID)
+	if err != nil {
+		return err
+	}
+	err = checkSequence(last, events)
+	if err != nil {
+		return err
+	}
+
+	insert := j.query(fmt.Sprintf("INSERT INTO %s (persistence_id, sequence, event_type, data, created_at) VALUES (?, ?, ?, ?, ?)", j.eventsTable))
+	for _, e := range events {
+		_, err = tx.ExecContext(ctx, insert, persistenceID, int64(e.Sequence), e.Type, e.Data, e.At.UnixNano())
+		if err != nil {
+			// a concurrent append of the same sequence violates the primary key
+			return fmt.Errorf("%w: %w", ErrSequenceConflict, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (j *SQLJournal) Replay(ctx context.Context, persistenceID string, from uint64, f func(Event) error) error {
+	rows, err := j.db.QueryContext(
+		ctx,
+		j.query(fmt.Sprintf("SELECT sequence, event_type, data, created_at FROM %s WHERE persistence_id = ? AND sequence >= ? ORDER BY sequence", j.eventsTable)),
+		persistenceID,
+		int64(from),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sequence, at int64
+		e := Event{PersistenceID: persistenceID}
+		err = rows.Scan(&sequence, &e.Type, &e.Data, &at)
+		if err != nil {
+			return err
+		}
+		e.Sequence = uint64(sequence)
+		e.At = time.Unix(0, at)
+		err = f(e)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (j *SQLJournal) LastSequence(ctx context.Context, persistenceID string) (uint64, error) {
+	return j.lastSequence(ctx, j.db, persistenceID)
+}
+
+type sqlQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (j *SQLJournal) lastSequence(ctx context.Context, q sqlQuerier, persistenceID string) (uint64, error) {
+	var last sql.NullInt64
+	err := q.QueryRowContext(
+		ctx,
+		j.query(fmt.Sprintf("SELECT MAX(sequence) FROM %s WHERE persistence_id = ?", j.eventsTable)),
+		persistenceID,
+	).Scan(&last)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(last.Int64), nil
+}
+
+func (j *SQLJournal) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, j.query(fmt.Sprintf("DELETE FROM %s WHERE persistence_id = ?", j.snapshotsTable)), snapshot.PersistenceID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		j.query(fmt.Sprintf("INSERT INTO %s (persistence_id, sequence, data, created_at) VALUES (?, ?, ?, ?)", j.snapshotsTable)),
+		snapshot.PersistenceID,
+		int64(snapshot.Sequence),
+		snapshot.Data,
+		snapshot.At.UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (j *SQLJournal) LoadSnapshot(ctx context.Context, persistenceID string) (Snapshot, error) {
+	var sequence, at int64
+	snapshot := Snapshot{PersistenceID: persistenceID}
+	err := j.db.QueryRowContext(
+		ctx,
+		j.query(fmt.Sprintf("SELECT sequence, data, created_at FROM %s WHERE persistence_id = ?", j.snapshotsTable)),
+		persistenceID,
+	).Scan(&sequence, &snapshot.Data, &at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot.Sequence = uint64(sequence)
+	snapshot.At = time.Unix(0, at)
+	return snapshot, nil
+}
+
+// Close does nothing: the database is owned by the caller
+func (j *SQLJournal) Close() error {
+	return nil
+}
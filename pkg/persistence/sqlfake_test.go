@@ -0,0 +1,179 @@
+package persistence_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// fakeSQLDriver is a database/sql driver understanding only the statements of SQLJournal, to test it without a database engine.
+// Every DSN is a different database; transactions are not isolated and rollback does not undo the changes.
+type fakeSQLDriver struct {
+	mutex     sync.Mutex
+	databases map[string]*fakeSQLDatabase
+}
+
+type fakeSQLDatabase struct {
+	mutex     sync.Mutex
+	events    map[string][][]driver.Value
+	snapshots map[string][]driver.Value
+	queries   []string
+}
+
+var (
+	fakeSQL       = &fakeSQLDriver{databases: make(map[string]*fakeSQLDatabase)}
+	fakeSQLOpened atomic.Int64
+)
+
+func init() {
+	sql.Register("fakesql", fakeSQL)
+}
+
+// openFakeSQL opens a new empty fake database
+func openFakeSQL(name string) (*sql.DB, *fakeSQLDatabase) {
+	dsn := fmt.Sprintf("%s-%d", name, fakeSQLOpened.Add(1))
+	db, _ := sql.Open("fakesql", dsn)
+	return db, fakeSQL.database(dsn)
+}
+
+func (d *fakeSQLDriver) database(dsn string) *fakeSQLDatabase {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	database, ok := d.databases[dsn]
+	if !ok {
+		database = &fakeSQLDatabase{
+			events:    make(map[string][][]driver.Value),
+			snapshots: make(map[string][]driver.Value),
+		}
+		d.databases[dsn] = database
+	}
+	return database
+}
+
+func (d *fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return &fakeSQLConn{database: d.database(name)}, nil
+}
+
+type fakeSQLConn struct {
+	database *fakeSQLDatabase
+}
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{database: c.database, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeSQLConn) Close() error {
+	return nil
+}
+
+func (c *fakeSQLConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeSQLConn) Commit() error {
+	return nil
+}
+
+func (c *fakeSQLConn) Rollback() error {
+	return nil
+}
+
+type fakeSQLStmt struct {
+	database *fakeSQLDatabase
+	query    string
+}
+
+func (s *fakeSQLStmt) Close() error {
+	return nil
+}
+
+func (s *fakeSQLStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.database
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.queries = append(d.queries, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+	case strings.HasPrefix(s.query, "INSERT INTO") && strings.Contains(s.query, "events"):
+		id := args[0].(string)
+		for _, row := range d.events[id] {
+			if row[1] == args[1] {
+				return nil, errors.New("UNIQUE constraint failed")
+			}
+		}
+		d.events[id] = append(d.events[id], args)
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		d.snapshots[args[0].(string)] = args
+	case strings.HasPrefix(s.query, "DELETE FROM"):
+		delete(d.snapshots, args[0].(string))
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.database
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.queries = append(d.queries, s.query)
+
+	id := args[0].(string)
+	switch {
+	case strings.HasPrefix(s.query, "SELECT MAX(sequence)"):
+		var max driver.Value
+		for _, row := range d.events[id] {
+			if max == nil || row[1].(int64) > max.(int64) {
+				max = row[1]
+			}
+		}
+		return &fakeSQLRows{columns: []string{"max"}, rows: [][]driver.Value{{max}}}, nil
+	case strings.HasPrefix(s.query, "SELECT sequence, event_type"):
+		rows := make([][]driver.Value, 0)
+		for _, row := range d.events[id] {
+			if row[1].(int64) >= args[1].(int64) {
+				rows = append(rows, row[1:])
+			}
+		}
+		return &fakeSQLRows{columns: []string{"sequence", "event_type", "data", "created_at"}, rows: rows}, nil
+	case strings.HasPrefix(s.query, "SELECT sequence, data"):
+		rows := make([][]driver.Value, 0)
+		if row, ok := d.snapshots[id]; ok {
+			rows = append(rows, row[1:])
+		}
+		return &fakeSQLRows{columns: []string{"sequence", "data", "created_at"}, rows: rows}, nil
+	}
+	return nil, fmt.Errorf("unexpected query: %s", s.query)
+}
+
+type fakeSQLRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeSQLRows) Close() error {
+	return nil
+}
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
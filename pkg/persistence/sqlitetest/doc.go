@@ -0,0 +1,3 @@
+// Package sqlitetest tests the SQL journal of the persistence package against an embedded SQLite engine.
+// It is a separate module, so that the driver is not a dependency of cinecity.
+package sqlitetest
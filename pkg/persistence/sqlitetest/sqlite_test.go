@@ -0,0 +1,127 @@
+package sqlitetest_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pix303/cinecity/pkg/persistence"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func openSQLite(t *testing.T, path string) *sql.DB {
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func newEvents(id string, from uint64, types ...string) []persistence.Event {
+	events := make([]persistence.Event, 0, len(types))
+	for i, t := range types {
+		events = append(events, persistence.Event{
+			PersistenceID: id,
+			Sequence:      from + uint64(i),
+			Type:          t,
+			Data:          []byte(`{"type":"` + t + `"}`),
+			At:            time.Now(),
+		})
+	}
+	return events
+}
+
+func replayEvents(t *testing.T, j persistence.Journal, id string, from uint64) []persistence.Event {
+	events := make([]persistence.Event, 0)
+	err := j.Replay(context.Background(), id, from, func(e persistence.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	assert.NoError(t, err)
+	return events
+}
+
+func TestSQLiteJournal(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.db")
+	j, err := persistence.NewSQLJournal(ctx, openSQLite(t, path), persistence.WithSQLTablePrefix("app_"))
+	assert.NoError(t, err)
+
+	last, err := j.LastSequence(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), last)
+	assert.Empty(t, replayEvents(t, j, "products", 1))
+
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 1, "added", "added")))
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 3, "removed")))
+	assert.NoError(t, j.Append(ctx, "orders/order-1", newEvents("orders/order-1", 1, "created")))
+
+	err = j.Append(ctx, "products", newEvents("products", 3, "removed"))
+	assert.ErrorIs(t, err, persistence.ErrSequenceConflict, "a sequence already stored must be rejected")
+	err = j.Append(ctx, "products", newEvents("products", 5, "removed"))
+	assert.ErrorIs(t, err, persistence.ErrSequenceConflict, "a gap in the sequence must be rejected")
+
+	events := replayEvents(t, j, "products", 2)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, uint64(2), events[0].Sequence)
+		assert.Equal(t, "added", events[0].Type)
+		assert.Equal(t, []byte(`{"type":"added"}`), events[0].Data)
+		assert.Equal(t, "products", events[1].PersistenceID)
+		assert.Equal(t, "removed", events[1].Type)
+		assert.False(t, events[1].At.IsZero())
+	}
+	assert.Len(t, replayEvents(t, j, "orders/order-1", 0), 1)
+
+	stop := errors.New("stop")
+	err = j.Replay(ctx, "products", 1, func(e persistence.Event) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+
+	_, err = j.LoadSnapshot(ctx, "products")
+	assert.ErrorIs(t, err, persistence.ErrSnapshotNotFound)
+	assert.NoError(t, j.SaveSnapshot(ctx, persistence.Snapshot{PersistenceID: "products", Sequence: 2, Data: []byte("two"), At: time.Now()}))
+	assert.NoError(t, j.SaveSnapshot(ctx, persistence.Snapshot{PersistenceID: "products", Sequence: 3, Data: []byte("three"), At: time.Now()}))
+	snapshot, err := j.LoadSnapshot(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), snapshot.Sequence)
+	assert.Equal(t, []byte("three"), snapshot.Data, "the latest snapshot replaces the previous one")
+	assert.NoError(t, j.Close())
+
+	reopened, err := persistence.NewSQLJournal(ctx, openSQLite(t, path), persistence.WithSQLTablePrefix("app_"))
+	assert.NoError(t, err, "the tables already exist")
+	last, err = reopened.LastSequence(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), last, "events survive the process")
+}
+
+func TestSQLiteJournalRollsBackFailedAppend(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t, filepath.Join(t.TempDir(), "journal.db"))
+	j, err := persistence.NewSQLJournal(ctx, db)
+	assert.NoError(t, err)
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 1, "added")))
+
+	// the engine rejects the second event of the batch, after the first one is inserted
+	_, err = db.Exec(`CREATE TRIGGER reject_invalid BEFORE INSERT ON cinecity_events
+WHEN NEW.event_type = 'invalid'
+BEGIN
+	SELECT RAISE(ABORT, 'invalid event');
+END`)
+	assert.NoError(t, err)
+	err = j.Append(ctx, "products", newEvents("products", 2, "added", "invalid"))
+	assert.Error(t, err)
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM cinecity_events").Scan(&count))
+	assert.Equal(t, 1, count, "no event of the failed batch is stored")
+	last, err := j.LastSequence(ctx, "products")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), last)
+	assert.NoError(t, j.Append(ctx, "products", newEvents("products", 2, "added")))
+}
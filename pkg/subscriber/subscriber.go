@@ -6,42 +6,70 @@ import (
 	"github.com/pix303/cinecity/pkg/actor"
 )
 
+// Filter selects the message bodies delivered to a subscription
+type Filter = actor.Filter
+
+// BodyOf returns a filter accepting the bodies of type T, or implementing T if it is an interface
+func BodyOf[T any]() Filter {
+	return actor.BodyOf[T]()
+}
+
+// subscription is a subscriber interested in the messages selected by its matcher
+type subscription struct {
+	address *actor.Address
+	matcher actor.TopicMatcher
+}
+
 type Subscriptions struct {
-	subscribers []*actor.Address
+	subscriptions []*subscription
 }
 
 func NewSubscription() *Subscriptions {
 	return &Subscriptions{
-		subscribers: make([]*actor.Address, 0),
+		subscriptions: make([]*subscription, 0),
 	}
 }
 
-type AddSubscriptionMessageBody struct{}
+// AddSubscriptionMessageBody subscribes the sender to the topics matching Topics, with the wildcards "*" for one token
+// and ">" for the remaining ones (e.g. "orders.*"), or to all the messages if Topics is empty.
+// Filter, if not nil, selects the bodies delivered; it is not sent to remote notifiers.
+type AddSubscriptionMessageBody struct {
+	Topics []string
+	Filter Filter `json:"-"`
+}
 
 func NewAddSubcriptionMessage(subscriberAddress *actor.Address, notifierAddress *actor.Address) actor.Message {
+	return NewAddTopicSubscriptionMessage(subscriberAddress, notifierAddress, nil)
+}
+
+// NewAddTopicSubscriptionMessage subscribes to the topics matching the patterns and accepted by filter, that can be nil
+func NewAddTopicSubscriptionMessage(subscriberAddress *actor.Address, notifierAddress *actor.Address, filter Filter, patterns ...string) actor.Message {
 	return actor.Message{
 		From: subscriberAddress,
 		To:   notifierAddress,
-		Body: AddSubscriptionMessageBody{},
+		Body: AddSubscriptionMessageBody{Topics: patterns, Filter: filter},
 	}
 }
 
-type RemoveSubscriptionMessageBody struct{}
+// RemoveSubscriptionMessageBody removes the given topic patterns of the sender, or all its subscriptions if Topics is empty
+type RemoveSubscriptionMessageBody struct {
+	Topics []string
+}
 
-func NewRemoveSubscriptionMessage(subscriberAddress *actor.Address, notifierAddress *actor.Address) actor.Message {
+func NewRemoveSubscriptionMessage(subscriberAddress *actor.Address, notifierAddress *actor.Address, patterns ...string) actor.Message {
 	return actor.Message{
 		From: subscriberAddress,
 		To:   notifierAddress,
-		Body: RemoveSubscriptionMessageBody{},
+		Body: RemoveSubscriptionMessageBody{Topics: patterns},
 	}
 }
 
 func (actor *Subscriptions) Process(msg actor.Message) {
-	switch msg.Body.(type) {
+	switch body := msg.Body.(type) {
 	case AddSubscriptionMessageBody:
-		actor.addSubscription(msg.From)
+		actor.addSubscription(msg.From, body.Topics, body.Filter)
 	case RemoveSubscriptionMessageBody:
-		actor.removeSubscription(msg.From)
+		actor.removeSubscription(msg.From, body.Topics)
 	}
 }
 
@@ -52,27 +80,79 @@ func NewSubscribersMessage(from *actor.Address, body any) actor.Message {
 	}
 }
 
-func (state *Subscriptions) addSubscription(subscriberAddress *actor.Address) {
-	state.subscribers = append(state.subscribers, subscriberAddress)
+func (state *Subscriptions) addSubscription(subscriberAddress *actor.Address, patterns []string, filter Filter) {
+	if subscriberAddress == nil {
+		return
+	}
+	state.subscriptions = append(state.subscriptions, &subscription{
+		address: subscriberAddress,
+		matcher: actor.TopicMatcher{Patterns: append([]string{}, patterns...), Filter: filter},
+	})
+}
+
+// removeSubscription removes the patterns from the subscriptions of the subscriber, dropping the ones left without patterns
+func (state *Subscriptions) removeSubscription(subscriberAddress *actor.Address, patterns []string) {
+	if subscriberAddress == nil {
+		return
+	}
+	kept := state.subscriptions[:0]
+	for _, sub := range state.subscriptions {
+		if !sub.address.IsEqual(subscriberAddress) {
+			kept = append(kept, sub)
+			continue
+		}
+		if len(patterns) == 0 || len(sub.matcher.Patterns) == 0 {
+			continue
+		}
+		sub.matcher.Patterns = removePatterns(sub.matcher.Patterns, patterns)
+		if len(sub.matcher.Patterns) > 0 {
+			kept = append(kept, sub)
+		}
+	}
+	clear(state.subscriptions[len(kept):])
+	state.subscriptions = kept
 }
 
-func (state *Subscriptions) removeSubscription(subscriberAddress *actor.Address) {
-	for i, v := range state.subscribers {
-		if v.IsEqual(subscriberAddress) {
-			state.subscribers = append(state.subscribers[:i], state.subscribers[i+1:]...)
+func removePatterns(patterns []string, removed []string) []string {
+	kept := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		found := false
+		for _, r := range removed {
+			if pattern == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kept = append(kept, pattern)
 		}
 	}
+	return kept
 }
 
 func (state *Subscriptions) NumSubscribers() int {
-	return len(state.subscribers)
+	return len(state.subscriptions)
 }
 
+// NotifySubscribers sends the message to the subscribers of all the messages, see NotifyTopic; it returns the number of messages not sent
 func (state *Subscriptions) NotifySubscribers(msg actor.Message) int {
+	return state.NotifyTopic("", msg)
+}
+
+// NotifyTopic publishes the message on the topic: it is sent once to every subscriber with a subscription matching the topic
+// and accepting the body. The topic is not delivered, so subscribers tell the messages apart by the type of the body.
+// It returns the number of messages not sent.
+func (state *Subscriptions) NotifyTopic(topic string, msg actor.Message) int {
 	result := 0
-	for _, sub := range state.subscribers {
-		msg.To = sub
-		slog.Info("sending msg to subscriber", slog.String("msg", msg.String()), slog.String("subscriber", sub.String()))
+	notified := make([]*actor.Address, 0, len(state.subscriptions))
+	for _, sub := range state.subscriptions {
+		if !sub.matcher.Matches(topic, msg.Body) || containsAddress(notified, sub.address) {
+			continue
+		}
+		notified = append(notified, sub.address)
+
+		msg.To = sub.address
+		slog.Info("sending msg to subscriber", slog.String("msg", msg.String()), slog.String("subscriber", sub.address.String()), slog.String("topic", topic))
 		err := actor.SendMessage(msg)
 		if err != nil {
 			result++
@@ -81,3 +161,12 @@ func (state *Subscriptions) NotifySubscribers(msg actor.Message) int {
 	}
 	return result
 }
+
+func containsAddress(addresses []*actor.Address, address *actor.Address) bool {
+	for _, a := range addresses {
+		if a.IsEqual(address) {
+			return true
+		}
+	}
+	return false
+}
@@ -115,3 +115,88 @@ func TestNotifySubscribersWithDelivery(t *testing.T) {
 	assert.Equal(t, "test message", mockProcessor1.receivedMessages[0].Body, "message body should match")
 	assert.Equal(t, "test message", mockProcessor2.receivedMessages[0].Body, "message body should match")
 }
+
+type OrderCreated struct {
+	ID string
+}
+
+type OrderShipped struct {
+	ID string
+}
+
+// bodyCollector reports the bodies it receives
+type bodyCollector struct {
+	bodies chan any
+}
+
+func (c *bodyCollector) Process(msg actor.Message) {
+	c.bodies <- msg.Body
+}
+
+func (c *bodyCollector) Shutdown() {}
+
+func (c *bodyCollector) GetState() any {
+	return nil
+}
+
+func registerCollector(t *testing.T, id string) (*actor.Address, chan any) {
+	address := actor.NewAddress("topics", id)
+	collector := &bodyCollector{bodies: make(chan any, 10)}
+	_, err := actor.RegisterActor(address, collector)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		actor.UnRegisterActor(address)
+	})
+	return address, collector.bodies
+}
+
+func receivedBodies(bodies chan any) []any {
+	time.Sleep(50 * time.Millisecond)
+	result := make([]any, 0)
+	for len(bodies) > 0 {
+		result = append(result, <-bodies)
+	}
+	return result
+}
+
+func TestNotifyTopic(t *testing.T) {
+	actor.InitPostman()
+	orders, ordersBodies := registerCollector(t, "orders")
+	shipping, shippingBodies := registerCollector(t, "shipping")
+	all, allBodies := registerCollector(t, "all")
+
+	subsActor := subscriber.NewSubscription()
+	subsActor.Process(subscriber.NewAddTopicSubscriptionMessage(orders, nil, nil, "orders.*"))
+	subsActor.Process(subscriber.NewAddTopicSubscriptionMessage(orders, nil, nil, "orders.>", "payments.eu"))
+	subsActor.Process(subscriber.NewAddTopicSubscriptionMessage(shipping, nil, subscriber.BodyOf[OrderShipped](), "orders.>"))
+	subsActor.Process(subscriber.NewAddSubcriptionMessage(all, nil))
+	assert.Equal(t, 4, subsActor.NumSubscribers())
+
+	from := actor.NewAddress("topics", "notifier")
+	assert.Equal(t, 0, subsActor.NotifyTopic("orders.eu", subscriber.NewSubscribersMessage(from, OrderCreated{ID: "1"})))
+	assert.Equal(t, 0, subsActor.NotifyTopic("orders.eu.rome", subscriber.NewSubscribersMessage(from, OrderShipped{ID: "1"})))
+	assert.Equal(t, 0, subsActor.NotifyTopic("payments.us", subscriber.NewSubscribersMessage(from, "paid")))
+	assert.Equal(t, 0, subsActor.NotifySubscribers(subscriber.NewSubscribersMessage(from, "no topic")))
+
+	assert.Equal(t, []any{OrderCreated{ID: "1"}, OrderShipped{ID: "1"}}, receivedBodies(ordersBodies), "a subscriber receives a message once")
+	assert.Equal(t, []any{OrderShipped{ID: "1"}}, receivedBodies(shippingBodies), "filter selects the body type")
+	assert.Equal(t, []any{OrderCreated{ID: "1"}, OrderShipped{ID: "1"}, "paid", "no topic"}, receivedBodies(allBodies))
+}
+
+func TestRemoveTopicSubscription(t *testing.T) {
+	actor.InitPostman()
+	orders, ordersBodies := registerCollector(t, "orders")
+
+	subsActor := subscriber.NewSubscription()
+	subsActor.Process(subscriber.NewAddTopicSubscriptionMessage(orders, nil, nil, "orders.*", "payments.*"))
+	subsActor.Process(subscriber.NewRemoveSubscriptionMessage(orders, nil, "orders.*"))
+	assert.Equal(t, 1, subsActor.NumSubscribers())
+
+	from := actor.NewAddress("topics", "notifier")
+	subsActor.NotifyTopic("orders.eu", subscriber.NewSubscribersMessage(from, "created"))
+	subsActor.NotifyTopic("payments.eu", subscriber.NewSubscribersMessage(from, "paid"))
+	assert.Equal(t, []any{"paid"}, receivedBodies(ordersBodies))
+
+	subsActor.Process(subscriber.NewRemoveSubscriptionMessage(orders, nil, "payments.*"))
+	assert.Equal(t, 0, subsActor.NumSubscribers(), "subscription without topics left is removed")
+}